@@ -2,8 +2,14 @@ package oauth1
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"sort"
@@ -24,12 +30,19 @@ const (
 	oauthVersionParam         = "oauth_version"
 	oauthCallbackParam        = "oauth_callback"
 	oauthVerifierParam        = "oauth_verifier"
+	oauthBodyHashParam        = "oauth_body_hash"
+	xoauthRequestorIDParam    = "xoauth_requestor_id"
 	defaultOauthVersion       = "1.0"
 	contentType               = "Content-Type"
 	formContentType           = "application/x-www-form-urlencoded"
 	realmParam                = "realm"
+	defaultMaxFormBodySize    = 10 << 20 // 10 MB
 )
 
+// ErrFormBodyTooLarge is returned when signing a request whose form encoded
+// body exceeds the Config MaxFormBodySize.
+var ErrFormBodyTooLarge = errors.New("oauth1: form body too large to sign")
+
 // clock provides a interface for current time providers. A Clock can be used
 // in place of calling time.Now() directly.
 type clock interface {
@@ -55,68 +68,185 @@ func newAuther(config *Config) *auther {
 }
 
 // setRequestTokenAuthHeader adds the OAuth1 header for the request token
-// request (temporary credential) according to RFC 5849 2.1.
-func (a *auther) setRequestTokenAuthHeader(req *http.Request) error {
+// request (temporary credential) according to RFC 5849 2.1, with the given
+// callback URL. OAuth 1.0 providers receive the callback on the
+// authorization URL instead.
+func (a *auther) setRequestTokenAuthHeader(req *http.Request, callbackURL string) error {
 	oauthParams := a.commonOAuthParams()
-	oauthParams[oauthCallbackParam] = a.config.CallbackURL
-	params, err := collectParameters(req, oauthParams)
-	if err != nil {
-		return err
-	}
-	signatureBase := signatureBase(req, params)
-	signature, err := a.signer().Sign("", signatureBase)
-	if err != nil {
-		return err
+	if a.config.ProtocolVersion != OAuth10 {
+		oauthParams[oauthCallbackParam] = callbackURL
 	}
-	oauthParams[oauthSignatureParam] = signature
-	if a.config.Realm != "" {
-		oauthParams[realmParam] = a.config.Realm
-	}
-	req.Header.Set(authorizationHeaderParam, authHeaderValue(oauthParams))
-	return nil
+	return a.setSignedAuthHeader(req, oauthParams, nil, "")
 }
 
 // setAccessTokenAuthHeader sets the OAuth1 header for the access token request
-// (token credential) according to RFC 5849 2.3.
+// (token credential) according to RFC 5849 2.3. OAuth 1.0 providers have no
+// verifier, so an empty verifier is omitted.
 func (a *auther) setAccessTokenAuthHeader(req *http.Request, requestToken, requestSecret, verifier string) error {
 	oauthParams := a.commonOAuthParams()
 	oauthParams[oauthTokenParam] = requestToken
-	oauthParams[oauthVerifierParam] = verifier
-	params, err := collectParameters(req, oauthParams)
-	if err != nil {
-		return err
+	if a.config.ProtocolVersion != OAuth10 || verifier != "" {
+		oauthParams[oauthVerifierParam] = verifier
 	}
-	signatureBase := signatureBase(req, params)
-	signature, err := a.signer().Sign(requestSecret, signatureBase)
-	if err != nil {
-		return err
-	}
-	oauthParams[oauthSignatureParam] = signature
-	req.Header.Set(authorizationHeaderParam, authHeaderValue(oauthParams))
-	return nil
+	return a.setSignedAuthHeader(req, oauthParams, nil, requestSecret)
+}
+
+// setXAuthAuthHeader sets the OAuth1 header for an xAuth access token request.
+// The x_auth_* parameters are signed from the request's form body.
+func (a *auther) setXAuthAuthHeader(req *http.Request) error {
+	return a.setSignedAuthHeader(req, a.commonOAuthParams(), nil, "")
+}
+
+// setRefreshTokenAuthHeader sets the OAuth1 header for the request to renew
+// an expiring access token, according to the OAuth Session Extension.
+func (a *auther) setRefreshTokenAuthHeader(req *http.Request, accessToken *Token) error {
+	oauthParams := a.commonOAuthParams()
+	oauthParams[oauthTokenParam] = accessToken.Token
+	oauthParams[oauthSessionHandleParam] = accessToken.SessionHandle
+	return a.setSignedAuthHeader(req, oauthParams, nil, accessToken.TokenSecret)
 }
 
 // setRequestAuthHeader sets the OAuth1 header for making authenticated
 // requests with an AccessToken (token credential) according to RFC 5849 3.1.
 func (a *auther) setRequestAuthHeader(req *http.Request, accessToken *Token) error {
+	return a.setRequestAuthHeaderWithOptions(req, accessToken, RequestOptions{})
+}
+
+// setRequestAuthHeaderWithOptions sets the OAuth1 header for making
+// authenticated requests with an AccessToken, applying the realm, extra
+// protocol parameters, body hash, and signed body parameters of the given
+// RequestOptions. If the AccessToken is nil, the request is signed two-legged,
+// with only the consumer secret.
+func (a *auther) setRequestAuthHeaderWithOptions(req *http.Request, accessToken *Token, opts RequestOptions) error {
 	oauthParams := a.commonOAuthParams()
+	for key, value := range opts.Params {
+		if err := checkExtraOAuthParam(key); err != nil {
+			return err
+		}
+		oauthParams[key] = value
+	}
+	if opts.Realm != "" {
+		oauthParams[realmParam] = opts.Realm
+	}
+	if opts.BodyHash {
+		hash, err := a.bodyHash(req)
+		if err != nil {
+			return err
+		}
+		oauthParams[oauthBodyHashParam] = hash
+	}
+	if accessToken == nil {
+		if a.config.EmptyTokenParam {
+			oauthParams[oauthTokenParam] = ""
+		}
+		return a.setSignedAuthHeader(req, oauthParams, opts.SignedParams, "")
+	}
 	oauthParams[oauthTokenParam] = accessToken.Token
-	params, err := collectParameters(req, oauthParams)
+	return a.setSignedAuthHeader(req, oauthParams, opts.SignedParams, accessToken.TokenSecret)
+}
+
+// setSignedAuthHeader signs the request using the given OAuth parameters
+// (which should exclude oauth_signature), signed body parameters which are
+// not collected from the request (e.g. multipart fields), and token secret
+// and sets the OAuth1 Authorization header. If the Endpoint Profile places
+// parameters in the form body or query instead, they are sent there.
+func (a *auther) setSignedAuthHeader(req *http.Request, oauthParams, signedParams map[string]string, tokenSecret string) error {
+	err := a.sign(req, oauthParams, signedParams, tokenSecret)
 	if err != nil {
 		return err
 	}
+	switch a.config.profile().ParamPlacement {
+	case FormBody:
+		return setFormParams(req, oauthParams)
+	case QueryString:
+		setQueryParams(req, oauthParams)
+		return nil
+	}
+	req.Header.Set(authorizationHeaderParam, authHeaderValue(oauthParams))
+	return nil
+}
+
+// setFormParams adds OAuth parameters to the form encoded body of a request
+// according to RFC 5849 3.5.2, or to the query of a GET or HEAD request
+// without a body. The realm parameter is only sent in the Authorization
+// header, so it is dropped.
+func setFormParams(req *http.Request, oauthParams map[string]string) error {
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if !hasBody && (req.Method == "GET" || req.Method == "HEAD") {
+		setQueryParams(req, oauthParams)
+		return nil
+	}
+	var body []byte
+	if hasBody {
+		if !isFormContentType(req.Header.Get(contentType)) {
+			return errors.New("oauth1: OAuth parameters can only be added to a form encoded body")
+		}
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	params := map[string]string{}
+	for key, value := range oauthParams {
+		if key != realmParam {
+			params[key] = value
+		}
+	}
+	if len(body) > 0 {
+		body = append(body, '&')
+	}
+	body = append(body, normalizedParameterString(params)...)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set(contentType, formContentType)
+	return nil
+}
+
+// setQueryParams adds OAuth parameters, sorted by key, to the query of a
+// request according to RFC 5849 3.5.3. The realm parameter is dropped.
+func setQueryParams(req *http.Request, oauthParams map[string]string) {
+	keys := make([]string, 0, len(oauthParams))
+	for key := range oauthParams {
+		if key != realmParam {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		setQueryParam(req, key, oauthParams[key])
+	}
+}
+
+// sign computes the signature of the request using the given OAuth
+// parameters, signed body parameters, and token secret, and adds it to the
+// OAuth parameters as oauth_signature.
+func (a *auther) sign(req *http.Request, oauthParams, signedParams map[string]string, tokenSecret string) error {
+	params, err := collectParameters(req, oauthParams, a.maxFormBodySize())
+	if err != nil {
+		return err
+	}
+	for key, value := range signedParams {
+		if _, ok := params[key]; !ok {
+			params[key] = value
+		}
+	}
 	signatureBase := signatureBase(req, params)
-	signature, err := a.signer().Sign(accessToken.TokenSecret, signatureBase)
+	signature, err := a.signer().Sign(tokenSecret, signatureBase)
 	if err != nil {
 		return err
 	}
 	oauthParams[oauthSignatureParam] = signature
-	req.Header.Set(authorizationHeaderParam, authHeaderValue(oauthParams))
 	return nil
 }
 
 // commonOAuthParams returns a map of the common OAuth1 protocol parameters,
-// excluding the oauth_signature parameter. This includes the realm parameter
+// excluding the oauth_signature parameter and, if the Endpoint Profile omits
+// it, the oauth_version parameter. This includes the realm parameter
 // if it was set in the config. The realm parameter will not be included in
 // the signature base string as specified in RFC 5849 3.4.1.3.1.
 func (a *auther) commonOAuthParams() map[string]string {
@@ -127,12 +257,64 @@ func (a *auther) commonOAuthParams() map[string]string {
 		oauthNonceParam:           a.nonce(),
 		oauthVersionParam:         defaultOauthVersion,
 	}
+	if a.config.profile().OmitVersion {
+		delete(params, oauthVersionParam)
+	}
 	if a.config.Realm != "" {
 		params[realmParam] = a.config.Realm
 	}
 	return params
 }
 
+// checkExtraOAuthParam returns an error if the given key may not be set as an
+// extra protocol parameter. Extra parameters must be oauth_* or xoauth_*
+// parameters and may not override those computed during signing.
+func checkExtraOAuthParam(key string) error {
+	switch key {
+	case oauthConsumerKeyParam, oauthNonceParam, oauthSignatureParam, oauthSignatureMethodParam, oauthTimestampParam, oauthTokenParam, oauthVersionParam, oauthBodyHashParam:
+		return fmt.Errorf("oauth1: parameter %q is reserved", key)
+	}
+	if !strings.HasPrefix(key, "oauth_") && !strings.HasPrefix(key, "xoauth_") {
+		return fmt.Errorf("oauth1: parameter %q is not an oauth_ or xoauth_ parameter", key)
+	}
+	return nil
+}
+
+// bodyHash returns the oauth_body_hash of the request body according to the
+// OAuth Request Body Hash extension. The body is read and replaced so the
+// request can still be sent and replayed. Form encoded bodies are signed as
+// parameters instead and may not be hashed.
+func (a *auther) bodyHash(req *http.Request) (string, error) {
+	if isFormContentType(req.Header.Get(contentType)) {
+		return "", errors.New("oauth1: oauth_body_hash must not be used with form encoded bodies")
+	}
+	var b []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		b, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}
+	}
+	return bodyHashValue(a.signer().Name(), b), nil
+}
+
+// bodyHashValue returns the base64 encoded hash of a body, using SHA-256 for
+// the HMAC-SHA256 signature method and SHA-1 otherwise.
+func bodyHashValue(signatureMethod string, body []byte) string {
+	if signatureMethod == "HMAC-SHA256" {
+		sum := sha256.Sum256(body)
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+	sum := sha1.Sum(body)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
 // Returns a nonce using the configured Noncer.
 func (a *auther) nonce() string {
 	return a.config.Noncer.Nonce()
@@ -146,6 +328,14 @@ func (a *auther) epoch() int64 {
 	return time.Now().Unix()
 }
 
+// Returns the Config's maximum form body size or the default maximum.
+func (a *auther) maxFormBodySize() int64 {
+	if a.config.MaxFormBodySize > 0 {
+		return a.config.MaxFormBodySize
+	}
+	return defaultMaxFormBodySize
+}
+
 // Returns the Config's Signer or the default Signer.
 func (a *auther) signer() Signer {
 	if a.config.Signer != nil {
@@ -196,21 +386,29 @@ func sortParameters(params map[string]string, format string) []string {
 // collectParameters collects request parameters from the request query, OAuth
 // parameters (which should exclude oauth_signature), and the request body
 // provided the body is single part, form encoded, and the form content type
-// header is set. The returned map of collected parameter keys and values
-// follow RFC 5849 3.4.1.3, except duplicate parameters are not supported.
-func collectParameters(req *http.Request, oauthParams map[string]string) (map[string]string, error) {
+// header is set. Form bodies larger than maxFormBytes are not read and an
+// ErrFormBodyTooLarge error is returned. The returned map of collected
+// parameter keys and values follow RFC 5849 3.4.1.3, except duplicate
+// parameters are not supported.
+func collectParameters(req *http.Request, oauthParams map[string]string, maxFormBytes int64) (map[string]string, error) {
 	// add oauth, query, and body parameters into params
 	params := map[string]string{}
 	for key, value := range req.URL.Query() {
 		// most backends do not accept duplicate query keys
 		params[key] = value[0]
 	}
-	if req.Body != nil && req.Header.Get(contentType) == formContentType {
+	if req.Body != nil && req.Body != http.NoBody && isFormContentType(req.Header.Get(contentType)) {
+		if req.ContentLength > maxFormBytes {
+			return nil, ErrFormBodyTooLarge
+		}
 		// reads data to a []byte, draining req.Body
-		b, err := ioutil.ReadAll(req.Body)
+		b, err := ioutil.ReadAll(io.LimitReader(req.Body, maxFormBytes+1))
 		if err != nil {
 			return nil, err
 		}
+		if int64(len(b)) > maxFormBytes {
+			return nil, ErrFormBodyTooLarge
+		}
 		values, err := url.ParseQuery(string(b))
 		if err != nil {
 			return nil, err
@@ -219,8 +417,12 @@ func collectParameters(req *http.Request, oauthParams map[string]string) (map[st
 			// not supporting params with duplicate keys
 			params[key] = value[0]
 		}
-		// reinitialize Body with ReadCloser over the []byte
+		// reinitialize Body with ReadCloser over the []byte and keep GetBody
+		// able to replay the body (e.g. for retries and redirects)
 		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}
 	}
 	for key, value := range oauthParams {
 		// according to 3.4.1.3.1. the realm parameter is excluded
@@ -231,6 +433,14 @@ func collectParameters(req *http.Request, oauthParams map[string]string) (map[st
 	return params, nil
 }
 
+// isFormContentType reports whether a Content-Type header value has the
+// application/x-www-form-urlencoded media type, ignoring parameters such as
+// charset.
+func isFormContentType(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	return err == nil && mediaType == formContentType
+}
+
 // signatureBase combines the uppercase request method, percent encoded base
 // string URI, and normalizes the request parameters int a parameter string.
 // Returns the OAuth1 signature base string according to RFC5849 3.4.1.
@@ -244,22 +454,37 @@ func signatureBase(req *http.Request, params map[string]string) string {
 }
 
 // baseURI returns the base string URI of a request according to RFC 5849
-// 3.4.1.2. The scheme and host are lowercased, the port is dropped if it
-// is 80 or 443, and the path minus query parameters is included.
+// 3.4.1.2. The scheme and host are lowercased, the port is dropped if it is
+// the default port for the scheme (80 for http, 443 for https), and the
+// escaped path minus query parameters is included. The request Host, which
+// may override the URL host, is used if set.
 func baseURI(req *http.Request) string {
 	scheme := strings.ToLower(req.URL.Scheme)
-	host := strings.ToLower(req.URL.Host)
-	if hostPort := strings.Split(host, ":"); len(hostPort) == 2 && (hostPort[1] == "80" || hostPort[1] == "443") {
-		host = hostPort[0]
-	}
-	// TODO: use req.URL.EscapedPath() once Go 1.5 is more generally adopted
-	// For now, hacky workaround accomplishes the same internal escaping mode
-	// escape(u.Path, encodePath) for proper compliance with the OAuth1 spec.
-	path := req.URL.Path
-	if path != "" {
-		path = strings.Split(req.URL.RequestURI(), "?")[0]
-	}
-	return fmt.Sprintf("%v://%v%v", scheme, host, path)
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	path := req.URL.EscapedPath()
+	if path == "" && (scheme == "http" || scheme == "https") {
+		// an empty path is sent as "/" in the request line (RFC 3986 6.2.3)
+		path = "/"
+	}
+	return fmt.Sprintf("%v://%v%v", scheme, normalizeHost(scheme, host), path)
+}
+
+// normalizeHost lowercases a host, which may be an IPv6 literal, and drops
+// the port if it is the default port for the scheme.
+func normalizeHost(scheme, host string) string {
+	u := &url.URL{Host: host}
+	hostname, port := strings.ToLower(u.Hostname()), u.Port()
+	if strings.Contains(hostname, ":") {
+		// IPv6 literal with an optional RFC 6874 zone identifier
+		hostname = "[" + strings.Replace(hostname, "%", "%25", 1) + "]"
+	}
+	if port == "" || (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		return hostname
+	}
+	return hostname + ":" + port
 }
 
 // parameterString normalizes collected OAuth parameters (which should exclude
@@ -1,6 +1,8 @@
 package oauth1
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
@@ -49,6 +51,22 @@ func TestCommonOAuthParams(t *testing.T) {
 				"realm":                  "photos",
 			},
 		},
+		{
+			&auther{
+				&Config{
+					ConsumerKey: "some_consumer_key",
+					Noncer:      &fixedNoncer{"some_nonce"},
+					Endpoint:    Endpoint{Profile: &Profile{OmitVersion: true}},
+				},
+				&fixedClock{time.Unix(50037133, 0)},
+			},
+			map[string]string{
+				"oauth_consumer_key":     "some_consumer_key",
+				"oauth_signature_method": "HMAC-SHA1",
+				"oauth_timestamp":        "50037133",
+				"oauth_nonce":            "some_nonce",
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -194,7 +212,7 @@ func TestCollectParameters(t *testing.T) {
 	req, err := http.NewRequest("POST", "/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b", strings.NewReader(values.Encode()))
 	assert.Nil(t, err)
 	req.Header.Set(contentType, formContentType)
-	params, err := collectParameters(req, oauthParams)
+	params, err := collectParameters(req, oauthParams, defaultMaxFormBodySize)
 	// assert parameters were collected from oauthParams, the query, and form body
 	// excluding the realm parameter
 	expected := map[string]string{
@@ -223,6 +241,191 @@ func TestCollectParameters(t *testing.T) {
 	// http://golang.org/src/net/http/request.go#L837
 }
 
+func TestCollectParameters_GetBody(t *testing.T) {
+	values := url.Values{}
+	values.Add("status", "hello")
+	req, err := http.NewRequest("POST", "https://example.com", ioutil.NopCloser(strings.NewReader(values.Encode())))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, formContentType)
+	_, err = collectParameters(req, map[string]string{}, defaultMaxFormBodySize)
+	assert.Nil(t, err)
+	// assert the drained body was reinitialized and can be replayed
+	if assert.NotNil(t, req.GetBody) {
+		body, err := req.GetBody()
+		assert.Nil(t, err)
+		b, err := ioutil.ReadAll(body)
+		assert.Nil(t, err)
+		assert.Equal(t, "status=hello", string(b))
+	}
+	b, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "status=hello", string(b))
+}
+
+func TestCollectParameters_FormContentType(t *testing.T) {
+	cases := []struct {
+		contentType string
+		signed      bool
+	}{
+		{"application/x-www-form-urlencoded", true},
+		{"application/x-www-form-urlencoded; charset=UTF-8", true},
+		{"Application/X-WWW-Form-URLEncoded;charset=utf-8", true},
+		{"application/json", false},
+		{"multipart/form-data; boundary=xyz", false},
+		{"application/x-www-form-urlencoded-not", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "https://example.com", strings.NewReader("status=hello"))
+		assert.Nil(t, err)
+		req.Header.Set(contentType, c.contentType)
+		params, err := collectParameters(req, map[string]string{}, defaultMaxFormBodySize)
+		assert.Nil(t, err)
+		if c.signed {
+			assert.Equal(t, map[string]string{"status": "hello"}, params, c.contentType)
+		} else {
+			assert.Equal(t, map[string]string{}, params, c.contentType)
+		}
+	}
+}
+
+func TestCollectParameters_FormBodyTooLarge(t *testing.T) {
+	body := "status=" + strings.Repeat("a", 100)
+	// known and unknown (streamed) content lengths
+	bodies := []io.Reader{strings.NewReader(body), ioutil.NopCloser(strings.NewReader(body))}
+	for _, b := range bodies {
+		req, err := http.NewRequest("POST", "https://example.com", b)
+		assert.Nil(t, err)
+		req.Header.Set(contentType, formContentType)
+		params, err := collectParameters(req, map[string]string{}, 64)
+		assert.Nil(t, params)
+		assert.Equal(t, ErrFormBodyTooLarge, err)
+	}
+	// bodies within the limit are signed
+	req, err := http.NewRequest("POST", "https://example.com", strings.NewReader(body))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, formContentType)
+	params, err := collectParameters(req, map[string]string{}, int64(len(body)))
+	assert.Nil(t, err)
+	assert.Len(t, params["status"], 100)
+}
+
+func TestMaxFormBodySize(t *testing.T) {
+	a := newAuther(&Config{})
+	assert.Equal(t, int64(defaultMaxFormBodySize), a.maxFormBodySize())
+	a = newAuther(&Config{MaxFormBodySize: 1024})
+	assert.Equal(t, int64(1024), a.maxFormBodySize())
+}
+
+func TestBodyHash(t *testing.T) {
+	cases := []struct {
+		signer   Signer
+		body     io.Reader
+		expected string
+	}{
+		// example from the OAuth Request Body Hash extension
+		{&HMACSigner{}, strings.NewReader("Hello World!"), "Lve95gjOVATpfV8EL5X4nxwjKHE="},
+		{&HMACSigner{}, nil, "2jmj7l5rSw0yVb/vlWAYkK/YBwk="},
+		{&HMAC256Signer{}, strings.NewReader("Hello World!"), "f4OxZX/x/FO5LcGBSKHWXfwtSx+j1ncoSt3SABJtkGk="},
+	}
+	for _, c := range cases {
+		a := newAuther(&Config{Signer: c.signer})
+		req, err := http.NewRequest("POST", "https://example.com", c.body)
+		assert.Nil(t, err)
+		req.Header.Set(contentType, "text/plain")
+		hash, err := a.bodyHash(req)
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, hash)
+		if c.body != nil {
+			// assert the drained body was reinitialized and can be replayed
+			b, err := ioutil.ReadAll(req.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, "Hello World!", string(b))
+			body, err := req.GetBody()
+			assert.Nil(t, err)
+			b, err = ioutil.ReadAll(body)
+			assert.Nil(t, err)
+			assert.Equal(t, "Hello World!", string(b))
+		}
+	}
+}
+
+func TestBodyHash_FormBody(t *testing.T) {
+	a := newAuther(&Config{})
+	req, err := http.NewRequest("POST", "https://example.com", strings.NewReader("status=hello"))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, formContentType)
+	_, err = a.bodyHash(req)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: oauth_body_hash must not be used with form encoded bodies", err.Error())
+	}
+}
+
+func TestSetSignedAuthHeader_FormBody(t *testing.T) {
+	a := &auther{
+		&Config{
+			ConsumerKey: "consumer_key",
+			Realm:       "photos",
+			Noncer:      &fixedNoncer{"some_nonce"},
+			Endpoint:    Endpoint{Profile: &Profile{ParamPlacement: FormBody, OmitVersion: true}},
+		},
+		&fixedClock{time.Unix(50037133, 0)},
+	}
+	req, err := http.NewRequest("POST", "https://example.com/statuses", strings.NewReader("status=hello"))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, formContentType)
+	err = a.setRequestAuthHeader(req, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, req.Header.Get(authorizationHeaderParam))
+	b, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	values, err := url.ParseQuery(string(b))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", values.Get("status"))
+	assert.Equal(t, "consumer_key", values.Get(oauthConsumerKeyParam))
+	assert.NotEmpty(t, values.Get(oauthSignatureParam))
+	// assert realm is only sent in the Authorization header
+	assert.Empty(t, values.Get(realmParam))
+	assert.Equal(t, int64(len(b)), req.ContentLength)
+
+	// requests without a body are signed in the query
+	req, err = http.NewRequest("GET", "https://example.com/statuses?count=2", nil)
+	assert.Nil(t, err)
+	err = a.setRequestAuthHeader(req, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", req.URL.Query().Get("count"))
+	assert.Equal(t, "consumer_key", req.URL.Query().Get(oauthConsumerKeyParam))
+
+	// non-form bodies cannot carry parameters
+	req, err = http.NewRequest("POST", "https://example.com/statuses", strings.NewReader("{}"))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, "application/json")
+	err = a.setRequestAuthHeader(req, nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: OAuth parameters can only be added to a form encoded body", err.Error())
+	}
+}
+
+func TestSetSignedAuthHeader_QueryString(t *testing.T) {
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		Endpoint:       Endpoint{Profile: &Profile{ParamPlacement: QueryString}},
+	}
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "2", req.URL.Query().Get("count"))
+		params, err := config.VerifyRequest(req, "token_secret")
+		assert.Nil(t, err)
+		assert.Equal(t, "token", params[oauthTokenParam])
+	})
+	defer server.Close()
+
+	client := config.Client(NoContext, NewToken("token", "token_secret"))
+	_, err := client.Get(server.URL + "/statuses?count=2")
+	assert.Nil(t, err)
+}
+
 func TestSignatureBase(t *testing.T) {
 	reqA, err := http.NewRequest("get", "HTTPS://HELLO.IO?q=test", nil)
 	assert.Nil(t, err)
@@ -233,8 +436,8 @@ func TestSignatureBase(t *testing.T) {
 		params        map[string]string
 		signatureBase string
 	}{
-		{reqA, map[string]string{"a": "b", "c": "d"}, "GET&https%3A%2F%2Fhello.io&a%3Db%26c%3Dd"},
-		{reqB, map[string]string{"a": "b"}, "POST&http%3A%2F%2Fhello.io%3A8080&a%3Db"},
+		{reqA, map[string]string{"a": "b", "c": "d"}, "GET&https%3A%2F%2Fhello.io%2F&a%3Db%26c%3Dd"},
+		{reqB, map[string]string{"a": "b"}, "POST&http%3A%2F%2Fhello.io%3A8080%2F&a%3Db"},
 	}
 	// assert that method is uppercased, base uri rules applied, queries added, joined by &
 	for _, c := range cases {
@@ -255,7 +458,7 @@ func TestBaseURI(t *testing.T) {
 	}{
 		{reqA, "http://example.com/r%20v/X"},
 		{reqB, "https://www.example.net:8080/"},
-		{reqC, "https://example.com"},
+		{reqC, "https://example.com/"},
 	}
 	for _, c := range cases {
 		baseURI := baseURI(c.req)
@@ -263,6 +466,52 @@ func TestBaseURI(t *testing.T) {
 	}
 }
 
+func TestBaseURI_Normalization(t *testing.T) {
+	// RFC 5849 3.4.1.2 base string URI cases
+	cases := []struct {
+		url     string
+		host    string
+		baseURI string
+	}{
+		// scheme and host are lowercased
+		{"HTTP://EXAMPLE.COM/r%20v/X?id=123", "", "http://example.com/r%20v/X"},
+		// default ports for the scheme are dropped
+		{"http://example.com:80/", "", "http://example.com/"},
+		{"https://example.com:443/", "", "https://example.com/"},
+		// default ports for the other scheme are kept
+		{"https://example.com:80/", "", "https://example.com:80/"},
+		{"http://example.com:443/", "", "http://example.com:443/"},
+		{"https://www.example.net:8080/?q=1", "", "https://www.example.net:8080/"},
+		// IPv4 hosts
+		{"http://192.168.1.10:8080/api", "", "http://192.168.1.10:8080/api"},
+		{"http://192.168.1.10:80/api", "", "http://192.168.1.10/api"},
+		// IPv6 hosts keep their brackets
+		{"http://[::1]/api", "", "http://[::1]/api"},
+		{"http://[::1]:80/api", "", "http://[::1]/api"},
+		{"https://[2001:DB8::1]:8443/api", "", "https://[2001:db8::1]:8443/api"},
+		{"http://[fe80::1%25en0]:8080/api", "", "http://[fe80::1%25en0]:8080/api"},
+		// escaped paths are kept as sent (RawPath)
+		{"https://example.com/a%2Fb/c", "", "https://example.com/a%2Fb/c"},
+		{"https://example.com/caf%C3%A9", "", "https://example.com/caf%C3%A9"},
+		{"https://example.com/a b", "", "https://example.com/a%20b"},
+		// Host overrides the URL host (e.g. virtual hosts)
+		{"http://10.0.0.1:8080/api", "API.example.com", "http://api.example.com/api"},
+		{"https://10.0.0.1/api", "api.example.com:443", "https://api.example.com/api"},
+		{"https://10.0.0.1/api", "api.example.com:8443", "https://api.example.com:8443/api"},
+		// empty paths are normalized to "/"
+		{"https://example.com", "", "https://example.com/"},
+		{"http://example.com?q=1", "", "http://example.com/"},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", c.url, nil)
+		assert.Nil(t, err)
+		if c.host != "" {
+			req.Host = c.host
+		}
+		assert.Equal(t, c.baseURI, baseURI(req), c.url)
+	}
+}
+
 func TestNormalizedParameterString(t *testing.T) {
 	simple := map[string]string{
 		"a": "b & c",
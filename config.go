@@ -1,18 +1,41 @@
 package oauth1
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	oauthTokenSecretParam       = "oauth_token_secret"
 	oauthCallbackConfirmedParam = "oauth_callback_confirmed"
+	xAuthModeParam              = "x_auth_mode"
+	xAuthUsernameParam          = "x_auth_username"
+	xAuthPasswordParam          = "x_auth_password"
+	xAuthModeClientAuth         = "client_auth"
+)
+
+// ProtocolVersion is the revision of the OAuth1 authorization flow spoken by
+// a provider.
+type ProtocolVersion int
+
+const (
+	// OAuth10a is OAuth Core 1.0 Revision A (RFC 5849), the default. The
+	// callback is confirmed when obtaining a request token and a verifier is
+	// required to obtain an access token.
+	OAuth10a ProtocolVersion = iota
+	// OAuth10 is the original OAuth Core 1.0, spoken by some legacy
+	// providers. The callback is sent on the authorization URL instead, the
+	// callback is never confirmed, and there is no verifier.
+	OAuth10
 )
 
 // Config represents an OAuth1 consumer's (client's) key and secret, the
@@ -34,6 +57,31 @@ type Config struct {
 	Noncer Noncer
 	// HTTPClient overrides the choice of http.DefaultClient for RequestToken and AccessToken
 	HTTPClient *http.Client
+	// MaxFormBodySize is the maximum size in bytes of a form encoded request
+	// body which will be read to be signed (defaults to 10MB)
+	MaxFormBodySize int64
+	// EmptyTokenParam sends an empty oauth_token on two-legged (consumer-only)
+	// requests instead of omitting it, for providers which require it
+	EmptyTokenParam bool
+	// RestrictHosts limits request signing to the Endpoint hosts and
+	// AllowedHosts. Requests to other hosts (e.g. redirects to a CDN) are
+	// sent without an Authorization header
+	RestrictHosts bool
+	// AllowedHosts are additional hosts (e.g. "api.twitter.com") or scheme
+	// and hosts (e.g. "https://api.twitter.com") to sign requests for when
+	// RestrictHosts is set
+	AllowedHosts []string
+	// SignRedirects re-signs requests redirected to an allowed host for the
+	// redirect URL when RestrictHosts is set. Otherwise, redirected requests
+	// are sent without an Authorization header
+	SignRedirects bool
+	// ProtocolVersion of the provider's authorization flow (defaults to
+	// OAuth10a)
+	ProtocolVersion ProtocolVersion
+	// OnTokenInvalid is called when a client's request is rejected because
+	// the access token is invalid (e.g. revoked or expired). When set, the
+	// client's Transport returns a *TokenInvalidError for such responses
+	OnTokenInvalid func(err *TokenInvalidError)
 }
 
 // NewConfig returns a new Config with the given consumer key and secret.
@@ -51,10 +99,42 @@ func (c *Config) Client(ctx context.Context, t *Token) *http.Client {
 
 // NewClient returns a new http Client which signs requests via OAuth1.
 func NewClient(ctx context.Context, config *Config, token *Token) *http.Client {
+	return config.TokenSourceClient(ctx, StaticTokenSource(token))
+}
+
+// TokenSource returns a TokenSource which returns the given Token until it
+// nears expiry and then renews it with RefreshToken. Tokens without an
+// expiry are never renewed.
+func (c *Config) TokenSource(token *Token) TokenSource {
+	return &refreshingTokenSource{config: c, token: token}
+}
+
+// TokenSourceClient returns an HTTP client which uses the provided ctx and
+// signs requests with the Tokens returned by the TokenSource.
+func (c *Config) TokenSourceClient(ctx context.Context, source TokenSource) *http.Client {
 	transport := &Transport{
 		Base:   contextTransport(ctx),
-		source: StaticTokenSource(token),
-		auther: newAuther(config),
+		source: source,
+		auther: newAuther(c),
+	}
+	return &http.Client{Transport: transport}
+}
+
+// TwoLeggedClient returns an HTTP client which uses the provided ctx and
+// signs requests with only the consumer key and secret (two-legged OAuth).
+func (c *Config) TwoLeggedClient(ctx context.Context) *http.Client {
+	return NewTwoLeggedClient(ctx, c)
+}
+
+// NewTwoLeggedClient returns a new http Client which signs requests via
+// two-legged (consumer-only) OAuth1. Requests are signed without a token
+// secret and without an oauth_token, unless the request context carries a
+// Token via ContextWithToken.
+func NewTwoLeggedClient(ctx context.Context, config *Config) *http.Client {
+	transport := &Transport{
+		Base:      contextTransport(ctx),
+		auther:    newAuther(config),
+		twoLegged: true,
 	}
 	return &http.Client{Transport: transport}
 }
@@ -64,33 +144,57 @@ func NewClient(ctx context.Context, config *Config, token *Token) *http.Client {
 // RequestTokenURL. The response body form is validated to ensure
 // oauth_callback_confirmed is true. Returns the request token and secret
 // (temporary credentials).
+// For OAuth10 providers, oauth_callback is not sent and the callback is not
+// confirmed. The Endpoint Profile may change the HTTP method, parameter
+// placement, and response format.
 // See RFC 5849 2.1 Temporary Credentials.
 func (c *Config) RequestToken() (requestToken, requestSecret string, err error) {
-	req, err := http.NewRequest("POST", c.Endpoint.RequestTokenURL, nil)
-	if err != nil {
-		return "", "", err
+	return c.RequestTokenWithOptions(nil)
+}
+
+// RequestTokenOptions customize a request token (temporary credential)
+// request.
+type RequestTokenOptions struct {
+	// Params are extra provider parameters (e.g. x_auth_access_type, scope)
+	// to sign and send in the request token request query. Reserved oauth_*
+	// parameters may not be set.
+	Params url.Values
+	// CallbackURL overrides the Config CallbackURL for this login (e.g. to
+	// carry state from CallbackState.CallbackURL)
+	CallbackURL string
+}
+
+// RequestTokenWithOptions obtains a Request token and secret (temporary
+// credential) like RequestToken, but signs and sends the extra parameters of
+// the given RequestTokenOptions.
+func (c *Config) RequestTokenWithOptions(opts *RequestTokenOptions) (requestToken, requestSecret string, err error) {
+	if opts == nil {
+		opts = &RequestTokenOptions{}
 	}
-	err = newAuther(c).setRequestTokenAuthHeader(req)
+	requestTokenURL, err := url.Parse(c.Endpoint.RequestTokenURL)
 	if err != nil {
 		return "", "", err
 	}
-	resp, err := c.httpClient().Do(req)
+	if len(opts.Params) > 0 {
+		values := requestTokenURL.Query()
+		if err := setExtraParams(values, opts.Params); err != nil {
+			return "", "", err
+		}
+		requestTokenURL.RawQuery = values.Encode()
+	}
+	req, err := http.NewRequest(c.profile().requestTokenMethod(), requestTokenURL.String(), nil)
 	if err != nil {
 		return "", "", err
 	}
-	// when err is nil, resp contains a non-nil resp.Body which must be closed
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("oauth1: error reading Body: %v", err)
+	callbackURL := c.CallbackURL
+	if opts.CallbackURL != "" {
+		callbackURL = opts.CallbackURL
 	}
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return "", "", fmt.Errorf("oauth1: invalid status %d: %s", resp.StatusCode, body)
+	err = newAuther(c).setRequestTokenAuthHeader(req, callbackURL)
+	if err != nil {
+		return "", "", err
 	}
-
-	// ParseQuery to decode URL-encoded application/x-www-form-urlencoded body
-	values, err := url.ParseQuery(strings.TrimSpace(string(body)))
+	values, err := c.doTokenRequest(req)
 	if err != nil {
 		return "", "", err
 	}
@@ -99,7 +203,7 @@ func (c *Config) RequestToken() (requestToken, requestSecret string, err error)
 	if requestToken == "" || requestSecret == "" {
 		return "", "", errors.New("oauth1: Response missing oauth_token or oauth_token_secret")
 	}
-	if values.Get(oauthCallbackConfirmedParam) != "true" {
+	if c.ProtocolVersion != OAuth10 && values.Get(oauthCallbackConfirmedParam) != "true" {
 		return "", "", errors.New("oauth1: oauth_callback_confirmed was not true")
 	}
 	return requestToken, requestSecret, nil
@@ -108,14 +212,48 @@ func (c *Config) RequestToken() (requestToken, requestSecret string, err error)
 // AuthorizationURL accepts a request token and returns the *url.URL to the
 // Endpoint's authorization page that asks the user (resource owner) for to
 // authorize the consumer to act on his/her/its behalf.
+// For OAuth10 providers, the CallbackURL is added as the oauth_callback
+// parameter.
 // See RFC 5849 2.2 Resource Owner Authorization.
 func (c *Config) AuthorizationURL(requestToken string) (*url.URL, error) {
+	return c.AuthorizationURLWithOptions(requestToken, nil)
+}
+
+// AuthorizationURLOptions customize an authorization URL.
+type AuthorizationURLOptions struct {
+	// Params are extra provider query parameters (e.g. force_login,
+	// screen_name). Reserved oauth_* parameters may not be set.
+	Params url.Values
+	// CallbackURL overrides the Config CallbackURL sent to OAuth10
+	// providers for this login. It should match the RequestTokenOptions
+	// CallbackURL
+	CallbackURL string
+}
+
+// AuthorizationURLWithOptions returns the *url.URL to the Endpoint's
+// authorization page like AuthorizationURL, with the extra provider query
+// parameters and callback of the given AuthorizationURLOptions, which may be
+// nil.
+func (c *Config) AuthorizationURLWithOptions(requestToken string, opts *AuthorizationURLOptions) (*url.URL, error) {
+	if opts == nil {
+		opts = &AuthorizationURLOptions{}
+	}
 	authorizationURL, err := url.Parse(c.Endpoint.AuthorizeURL)
 	if err != nil {
 		return nil, err
 	}
 	values := authorizationURL.Query()
+	if err := setExtraParams(values, opts.Params); err != nil {
+		return nil, err
+	}
 	values.Add(oauthTokenParam, requestToken)
+	callbackURL := c.CallbackURL
+	if opts.CallbackURL != "" {
+		callbackURL = opts.CallbackURL
+	}
+	if c.ProtocolVersion == OAuth10 && callbackURL != "" {
+		values.Add(oauthCallbackParam, callbackURL)
+	}
 	authorizationURL.RawQuery = values.Encode()
 	return authorizationURL, nil
 }
@@ -139,46 +277,217 @@ func ParseAuthorizationCallback(req *http.Request) (requestToken, verifier strin
 	return requestToken, verifier, nil
 }
 
+// ParseAuthorizationCallback parses an OAuth1 authorization callback request
+// like the ParseAuthorizationCallback func, except that for OAuth10
+// providers the oauth_verifier parameter is optional and only the
+// oauth_token is required.
+func (c *Config) ParseAuthorizationCallback(req *http.Request) (requestToken, verifier string, err error) {
+	if c.ProtocolVersion != OAuth10 {
+		return ParseAuthorizationCallback(req)
+	}
+	err = req.ParseForm()
+	if err != nil {
+		return "", "", err
+	}
+	requestToken = req.Form.Get(oauthTokenParam)
+	if requestToken == "" {
+		return "", "", errors.New("oauth1: Request missing oauth_token")
+	}
+	return requestToken, req.Form.Get(oauthVerifierParam), nil
+}
+
 // AccessToken obtains an access token (token credential) by POSTing a
 // request (with oauth_token and oauth_verifier in the auth header) to the
 // Endpoint AccessTokenURL. Returns the access token and secret (token
 // credentials).
+// For OAuth10 providers, pass an empty verifier and oauth_verifier is not
+// sent. The Endpoint Profile may change the HTTP method, parameter
+// placement, and response format.
 // See RFC 5849 2.3 Token Credentials.
 func (c *Config) AccessToken(requestToken, requestSecret, verifier string) (accessToken, accessSecret string, err error) {
-	req, err := http.NewRequest("POST", c.Endpoint.AccessTokenURL, nil)
+	token, err := c.Exchange(requestToken, requestSecret, verifier)
 	if err != nil {
 		return "", "", err
 	}
+	return token.Token, token.TokenSecret, nil
+}
+
+// Exchange obtains an access Token (token credential) like AccessToken, but
+// returns a Token which includes any OAuth Session Extension expiry and
+// session handle and the other response parameters.
+// See RFC 5849 2.3 Token Credentials.
+func (c *Config) Exchange(requestToken, requestSecret, verifier string) (*Token, error) {
+	req, err := http.NewRequest(c.profile().accessTokenMethod(), c.Endpoint.AccessTokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
 	err = newAuther(c).setAccessTokenAuthHeader(req, requestToken, requestSecret, verifier)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+	values, err := c.doTokenRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return tokenFromValues(values, time.Now())
+}
+
+// XAuthAccessToken obtains an access Token (token credential) by exchanging
+// a user's username and password via xAuth. The x_auth_mode, x_auth_username,
+// and x_auth_password parameters are POSTed as a signed form body to the
+// Endpoint AccessTokenURL, skipping the request token and authorization
+// steps.
+func (c *Config) XAuthAccessToken(username, password string) (*Token, error) {
+	form := url.Values{}
+	form.Set(xAuthModeParam, xAuthModeClientAuth)
+	form.Set(xAuthUsernameParam, username)
+	form.Set(xAuthPasswordParam, password)
+	req, err := http.NewRequest("POST", c.Endpoint.AccessTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(contentType, formContentType)
+	err = newAuther(c).setXAuthAuthHeader(req)
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.doTokenRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return tokenFromValues(values, time.Now())
+}
+
+// SignForm signs a form encoded request to the given URL and returns a copy
+// of the form with the OAuth1 protocol parameters, including oauth_signature,
+// added. This places the protocol parameters in the request body (e.g. for
+// IMS LTI launches) rather than the Authorization header. A nil Token signs
+// the form two-legged, with only the consumer secret.
+// See RFC 5849 3.5.2 Form-Encoded Body.
+func (c *Config) SignForm(method, rawURL string, form url.Values, token *Token) (url.Values, error) {
+	req, err := http.NewRequest(method, rawURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(contentType, formContentType)
+	a := newAuther(c)
+	oauthParams := a.commonOAuthParams()
+	delete(oauthParams, realmParam)
+	tokenSecret := ""
+	if token != nil {
+		oauthParams[oauthTokenParam] = token.Token
+		tokenSecret = token.TokenSecret
+	}
+	err = a.sign(req, oauthParams, nil, tokenSecret)
+	if err != nil {
+		return nil, err
+	}
+	signed := url.Values{}
+	for key, values := range form {
+		signed[key] = append([]string(nil), values...)
+	}
+	for key, value := range oauthParams {
+		signed.Set(key, value)
+	}
+	return signed, nil
+}
+
+// RefreshToken renews an expiring access Token by POSTing a request (with
+// oauth_token and oauth_session_handle in the auth header) to the Endpoint
+// AccessTokenURL, as defined by the OAuth Session Extension. Returns the
+// renewed Token.
+func (c *Config) RefreshToken(token *Token) (*Token, error) {
+	if token == nil {
+		return nil, errors.New("oauth1: Token is nil")
+	}
+	if token.SessionHandle == "" {
+		return nil, errors.New("oauth1: Token has no session handle to renew")
+	}
+	req, err := http.NewRequest(c.profile().accessTokenMethod(), c.Endpoint.AccessTokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = newAuther(c).setRefreshTokenAuthHeader(req, token)
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.doTokenRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	renewed, err := tokenFromValues(values, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if renewed.SessionHandle == "" {
+		renewed.SessionHandle = token.SessionHandle
+		renewed.AuthorizationExpiry = token.AuthorizationExpiry
 	}
+	return renewed, nil
+}
+
+// setExtraParams sets extra provider parameters in values, returning an
+// error if any are reserved oauth_* parameters.
+func setExtraParams(values, params url.Values) error {
+	for key := range params {
+		if strings.HasPrefix(key, "oauth_") {
+			return fmt.Errorf("oauth1: parameter %q is reserved", key)
+		}
+	}
+	for key, vs := range params {
+		values[key] = append([]string(nil), vs...)
+	}
+	return nil
+}
+
+// doTokenRequest sends a signed token request and parses the response body,
+// which is form encoded or JSON according to the Endpoint Profile.
+func (c *Config) doTokenRequest(req *http.Request) (url.Values, error) {
 	resp, err := c.httpClient().Do(req)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	// when err is nil, resp contains a non-nil resp.Body which must be closed
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", fmt.Errorf("oauth1: error reading Body: %v", err)
+		return nil, fmt.Errorf("oauth1: error reading Body: %v", err)
 	}
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return "", "", fmt.Errorf("oauth1: invalid status %d: %s", resp.StatusCode, body)
+	profile := c.profile()
+	if !profile.isSuccess(resp.StatusCode) {
+		return nil, fmt.Errorf("oauth1: invalid status %d: %s", resp.StatusCode, body)
 	}
 
+	if profile.ResponseFormat == JSONResponse {
+		return parseJSONValues(body)
+	}
 	// ParseQuery to decode URL-encoded application/x-www-form-urlencoded body
-	values, err := url.ParseQuery(string(body))
-	if err != nil {
-		return "", "", err
+	return url.ParseQuery(strings.TrimSpace(string(body)))
+}
+
+// parseJSONValues decodes a JSON object token response into url.Values.
+// String, number, and boolean members are kept as their string forms (e.g.
+// "true") and other members are ignored.
+func parseJSONValues(body []byte) (url.Values, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var members map[string]interface{}
+	if err := decoder.Decode(&members); err != nil {
+		return nil, fmt.Errorf("oauth1: error decoding JSON response: %v", err)
 	}
-	accessToken = values.Get(oauthTokenParam)
-	accessSecret = values.Get(oauthTokenSecretParam)
-	if accessToken == "" || accessSecret == "" {
-		return "", "", errors.New("oauth1: Response missing oauth_token or oauth_token_secret")
+	values := url.Values{}
+	for key, member := range members {
+		switch v := member.(type) {
+		case string:
+			values.Set(key, v)
+		case json.Number:
+			values.Set(key, v.String())
+		case bool:
+			values.Set(key, strconv.FormatBool(v))
+		}
 	}
-	return accessToken, accessSecret, nil
+	return values, nil
 }
 
 func (c *Config) httpClient() *http.Client {
@@ -56,6 +56,76 @@ func TestNewClient_ContextClientTransport(t *testing.T) {
 	assert.Equal(t, baseTransport, transport.base())
 }
 
+func TestNewTwoLeggedClient(t *testing.T) {
+	expectedConsumerKey := "consumer_key"
+	config := NewConfig(expectedConsumerKey, "consumer_secret")
+	client := config.TwoLeggedClient(NoContext)
+
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, expectedConsumerKey, params[oauthConsumerKeyParam])
+		assert.NotEmpty(t, params[oauthSignatureParam])
+		// assert that oauth_token is omitted
+		_, ok := params[oauthTokenParam]
+		assert.False(t, ok)
+	})
+	defer server.Close()
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+}
+
+func TestNewTwoLeggedClient_EmptyTokenParam(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	config.EmptyTokenParam = true
+	client := config.TwoLeggedClient(NoContext)
+
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		// assert that an empty oauth_token is sent
+		token, ok := params[oauthTokenParam]
+		assert.True(t, ok)
+		assert.Equal(t, "", token)
+	})
+	defer server.Close()
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+}
+
+func TestNewTwoLeggedClient_RequestorID(t *testing.T) {
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		Noncer:         &fixedNoncer{"some_nonce"},
+	}
+	client := config.TwoLeggedClient(NoContext)
+
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "alice@example.com", req.URL.Query().Get("xoauth_requestor_id"))
+		assert.Equal(t, "2", req.URL.Query().Get("count"))
+		// assert the signature covers the requestor and uses only the consumer secret
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		signature, err := url.QueryUnescape(params[oauthSignatureParam])
+		assert.Nil(t, err)
+		delete(params, oauthSignatureParam)
+		params["xoauth_requestor_id"] = "alice@example.com"
+		params["count"] = "2"
+		req.URL.Scheme = "http"
+		req.URL.Host = req.Host
+		expected, err := (&HMACSigner{ConsumerSecret: "consumer_secret"}).Sign("", signatureBase(req, params))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, signature)
+	})
+	defer server.Close()
+
+	ctx := ContextWithRequestorID(context.Background(), "alice@example.com")
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/?count=2", nil)
+	assert.Nil(t, err)
+	_, err = client.Do(req)
+	assert.Nil(t, err)
+	// assert the original request was not modified
+	assert.Equal(t, "count=2", req.URL.RawQuery)
+}
+
 // newRequestTokenServer returns a new httptest.Server for an OAuth1 provider
 // request token endpoint.
 func newRequestTokenServer(t *testing.T, data url.Values) *httptest.Server {
@@ -147,6 +217,33 @@ func TestConfigRequestToken_CallbackNotConfirmed(t *testing.T) {
 	assert.Equal(t, "", requestSecret)
 }
 
+func TestConfigRequestToken_OAuth10(t *testing.T) {
+	data := url.Values{}
+	data.Add("oauth_token", "request_token")
+	data.Add("oauth_token_secret", "request_secret")
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		_, ok := params[oauthCallbackParam]
+		assert.False(t, ok)
+		w.Header().Set(contentType, formContentType)
+		w.Write([]byte(data.Encode()))
+	})
+	defer server.Close()
+
+	config := &Config{
+		CallbackURL: "https://example.com/callback",
+		Endpoint: Endpoint{
+			RequestTokenURL: server.URL,
+		},
+		ProtocolVersion: OAuth10,
+	}
+	// assert the callback need not be confirmed
+	requestToken, requestSecret, err := config.RequestToken()
+	assert.Nil(t, err)
+	assert.Equal(t, "request_token", requestToken)
+	assert.Equal(t, "request_secret", requestSecret)
+}
+
 func TestConfigRequestToken_CannotParseBody(t *testing.T) {
 	server := newUnparseableBodyServer()
 	defer server.Close()
@@ -164,6 +261,121 @@ func TestConfigRequestToken_CannotParseBody(t *testing.T) {
 	assert.Equal(t, "", requestSecret)
 }
 
+func TestConfigRequestToken_Profile(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "GET", req.Method)
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		_, ok := params[oauthVersionParam]
+		assert.False(t, ok)
+		w.Header().Set(contentType, "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"oauth_token": "request_token", "oauth_token_secret": "request_secret", "oauth_callback_confirmed": true}`))
+	})
+	defer server.Close()
+
+	config := &Config{
+		Endpoint: Endpoint{
+			RequestTokenURL: server.URL,
+			Profile: &Profile{
+				RequestTokenMethod: "GET",
+				OmitVersion:        true,
+				ResponseFormat:     JSONResponse,
+				SuccessStatuses:    []int{http.StatusAccepted},
+			},
+		},
+	}
+	requestToken, requestSecret, err := config.RequestToken()
+	assert.Nil(t, err)
+	assert.Equal(t, "request_token", requestToken)
+	assert.Equal(t, "request_secret", requestSecret)
+}
+
+func TestConfigRequestToken_ProfileSuccessStatuses(t *testing.T) {
+	data := url.Values{}
+	data.Add("oauth_token", "request_token")
+	data.Add("oauth_token_secret", "request_secret")
+	data.Add("oauth_callback_confirmed", "true")
+	server := newRequestTokenServer(t, data)
+	defer server.Close()
+
+	config := &Config{
+		Endpoint: Endpoint{
+			RequestTokenURL: server.URL,
+			Profile:         &Profile{SuccessStatuses: []int{http.StatusCreated}},
+		},
+	}
+	_, _, err := config.RequestToken()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "oauth1: invalid status 200")
+	}
+}
+
+func TestConfigRequestTokenWithOptions(t *testing.T) {
+	data := url.Values{}
+	data.Add("oauth_token", "request_token")
+	data.Add("oauth_token_secret", "request_secret")
+	data.Add("oauth_callback_confirmed", "true")
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+	}
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "read", req.URL.Query().Get("x_auth_access_type"))
+		assert.Equal(t, "v2", req.URL.Query().Get("api"))
+		// assert the extra parameters are signed
+		_, err := config.VerifyRequest(req, "")
+		assert.Nil(t, err)
+		w.Header().Set(contentType, formContentType)
+		w.Write([]byte(data.Encode()))
+	})
+	defer server.Close()
+
+	config.Endpoint.RequestTokenURL = server.URL + "/request_token?api=v2"
+	requestToken, requestSecret, err := config.RequestTokenWithOptions(&RequestTokenOptions{
+		Params: url.Values{"x_auth_access_type": {"read"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "request_token", requestToken)
+	assert.Equal(t, "request_secret", requestSecret)
+}
+
+func TestConfigRequestTokenWithOptions_CallbackURL(t *testing.T) {
+	const callbackURL = "https://example.com/callback?state=abc"
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, PercentEncode(callbackURL), params[oauthCallbackParam])
+		w.Header().Set(contentType, formContentType)
+		w.Write([]byte("oauth_token=request_token&oauth_token_secret=request_secret&oauth_callback_confirmed=true"))
+	})
+	defer server.Close()
+
+	config := &Config{
+		CallbackURL: "https://example.com/default",
+		Endpoint: Endpoint{
+			RequestTokenURL: server.URL,
+		},
+	}
+	requestToken, _, err := config.RequestTokenWithOptions(&RequestTokenOptions{CallbackURL: callbackURL})
+	assert.Nil(t, err)
+	assert.Equal(t, "request_token", requestToken)
+}
+
+func TestConfigRequestTokenWithOptions_ReservedParam(t *testing.T) {
+	config := &Config{
+		Endpoint: Endpoint{
+			RequestTokenURL: "https://api.example.com/oauth/request_token",
+		},
+	}
+	requestToken, requestSecret, err := config.RequestTokenWithOptions(&RequestTokenOptions{
+		Params: url.Values{"oauth_callback": {"https://evil.example.com"}},
+	})
+	assert.Equal(t, "", requestToken)
+	assert.Equal(t, "", requestSecret)
+	if assert.Error(t, err) {
+		assert.Equal(t, `oauth1: parameter "oauth_callback" is reserved`, err.Error())
+	}
+}
+
 func TestConfigRequestToken_MissingTokenOrSecret(t *testing.T) {
 	data := url.Values{}
 	data.Add("oauth_token", "any_token")
@@ -198,6 +410,43 @@ func TestAuthorizationURL(t *testing.T) {
 	}
 }
 
+func TestAuthorizationURL_OAuth10(t *testing.T) {
+	expectedURL := "https://api.example.com/oauth/authorize?oauth_callback=https%3A%2F%2Fexample.com%2Fcallback&oauth_token=request_token"
+	config := &Config{
+		CallbackURL: "https://example.com/callback",
+		Endpoint: Endpoint{
+			AuthorizeURL: "https://api.example.com/oauth/authorize",
+		},
+		ProtocolVersion: OAuth10,
+	}
+	url, err := config.AuthorizationURL("request_token")
+	assert.Nil(t, err)
+	if assert.NotNil(t, url) {
+		assert.Equal(t, expectedURL, url.String())
+	}
+}
+
+func TestAuthorizationURLWithOptions(t *testing.T) {
+	expectedURL := "https://api.example.com/oauth/authenticate?force_login=true&lang=en&oauth_token=request_token&screen_name=gopher"
+	config := &Config{
+		Endpoint: Endpoint{
+			AuthorizeURL: "https://api.example.com/oauth/authenticate?lang=en",
+		},
+	}
+	params := url.Values{"force_login": {"true"}, "screen_name": {"gopher"}}
+	authorizationURL, err := config.AuthorizationURLWithOptions("request_token", &AuthorizationURLOptions{Params: params})
+	assert.Nil(t, err)
+	if assert.NotNil(t, authorizationURL) {
+		assert.Equal(t, expectedURL, authorizationURL.String())
+	}
+
+	authorizationURL, err = config.AuthorizationURLWithOptions("request_token", &AuthorizationURLOptions{Params: url.Values{"oauth_token": {"other"}}})
+	assert.Nil(t, authorizationURL)
+	if assert.Error(t, err) {
+		assert.Equal(t, `oauth1: parameter "oauth_token" is reserved`, err.Error())
+	}
+}
+
 func TestAuthorizationURL_CannotParseAuthorizeURL(t *testing.T) {
 	config := &Config{
 		Endpoint: Endpoint{
@@ -232,6 +481,64 @@ func TestConfigAccessToken(t *testing.T) {
 	assert.Equal(t, expectedSecret, accessSecret)
 }
 
+func TestConfigAccessToken_OAuth10(t *testing.T) {
+	data := url.Values{}
+	data.Add("oauth_token", "access_token")
+	data.Add("oauth_token_secret", "access_secret")
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "request_token", params[oauthTokenParam])
+		_, ok := params[oauthVerifierParam]
+		assert.False(t, ok)
+		w.Header().Set(contentType, formContentType)
+		w.Write([]byte(data.Encode()))
+	})
+	defer server.Close()
+
+	config := &Config{
+		Endpoint: Endpoint{
+			AccessTokenURL: server.URL,
+		},
+		ProtocolVersion: OAuth10,
+	}
+	accessToken, accessSecret, err := config.AccessToken("request_token", "request_secret", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "access_token", accessToken)
+	assert.Equal(t, "access_secret", accessSecret)
+}
+
+func TestConfigExchange_Profile(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "PUT", req.Method)
+		assert.Empty(t, req.Header.Get(authorizationHeaderParam))
+		assert.Nil(t, req.ParseForm())
+		assert.Equal(t, "request_token", req.PostForm.Get(oauthTokenParam))
+		assert.Equal(t, expectedVerifier, req.PostForm.Get(oauthVerifierParam))
+		w.Header().Set(contentType, "application/json")
+		w.Write([]byte(`{"oauth_token": "access_token", "oauth_token_secret": "access_secret", "oauth_expires_in": 3600, "user": {"id": 1}}`))
+	})
+	defer server.Close()
+
+	config := &Config{
+		Endpoint: Endpoint{
+			AccessTokenURL: server.URL,
+			Profile: &Profile{
+				AccessTokenMethod: "PUT",
+				ParamPlacement:    FormBody,
+				ResponseFormat:    JSONResponse,
+			},
+		},
+	}
+	token, err := config.Exchange("request_token", "request_secret", expectedVerifier)
+	assert.Nil(t, err)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, "access_token", token.Token)
+		assert.Equal(t, "access_secret", token.TokenSecret)
+		assert.False(t, token.Expiry.IsZero())
+		assert.Equal(t, "3600", token.Extra("oauth_expires_in"))
+	}
+}
+
 func TestConfigAccessToken_InvalidAccessTokenURL(t *testing.T) {
 	config := &Config{
 		Endpoint: Endpoint{
@@ -280,6 +587,137 @@ func TestConfigAccessToken_MissingTokenOrSecret(t *testing.T) {
 	assert.Equal(t, "", accessSecret)
 }
 
+func TestConfigExchange(t *testing.T) {
+	data := url.Values{}
+	data.Add("oauth_token", "access_token")
+	data.Add("oauth_token_secret", "access_secret")
+	data.Add("oauth_expires_in", "3600")
+	data.Add("oauth_session_handle", "handle")
+	data.Add("screen_name", "gopher")
+	server := newAccessTokenServer(t, data)
+	defer server.Close()
+
+	config := &Config{
+		Endpoint: Endpoint{
+			AccessTokenURL: server.URL,
+		},
+	}
+	token, err := config.Exchange("request_token", "request_secret", expectedVerifier)
+	assert.Nil(t, err)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, "access_token", token.Token)
+		assert.Equal(t, "access_secret", token.TokenSecret)
+		assert.Equal(t, "handle", token.SessionHandle)
+		assert.False(t, token.Expiry.IsZero())
+		assert.Equal(t, "gopher", token.Extra("screen_name"))
+	}
+}
+
+func TestConfigRefreshToken(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "access_token", params[oauthTokenParam])
+		assert.Equal(t, "handle", params[oauthSessionHandleParam])
+		w.Header().Set(contentType, formContentType)
+		w.Write([]byte("oauth_token=renewed&oauth_token_secret=renewed_secret&oauth_expires_in=3600&oauth_session_handle=new_handle&oauth_authorization_expires_in=7200"))
+	})
+	defer server.Close()
+
+	config := &Config{
+		Endpoint: Endpoint{
+			AccessTokenURL: server.URL,
+		},
+	}
+	token := &Token{Token: "access_token", TokenSecret: "access_secret", SessionHandle: "handle"}
+	renewed, err := config.RefreshToken(token)
+	assert.Nil(t, err)
+	if assert.NotNil(t, renewed) {
+		assert.Equal(t, "renewed", renewed.Token)
+		assert.Equal(t, "renewed_secret", renewed.TokenSecret)
+		assert.Equal(t, "new_handle", renewed.SessionHandle)
+		assert.True(t, renewed.Expiry.Before(renewed.AuthorizationExpiry))
+	}
+}
+
+func TestConfigRefreshToken_MissingSessionHandle(t *testing.T) {
+	config := &Config{}
+	renewed, err := config.RefreshToken(NewToken("access_token", "access_secret"))
+	assert.Nil(t, renewed)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Token has no session handle to renew", err.Error())
+	}
+}
+
+func TestConfigTokenSourceClient(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "source_token", params[oauthTokenParam])
+	})
+	defer server.Close()
+
+	config := NewConfig("consumer_key", "consumer_secret")
+	client := config.TokenSourceClient(NoContext, StaticTokenSource(NewToken("source_token", "source_secret")))
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+}
+
+func TestConfigXAuthAccessToken(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		assert.Nil(t, req.ParseForm())
+		assert.Equal(t, "client_auth", req.PostForm.Get("x_auth_mode"))
+		assert.Equal(t, "gopher", req.PostForm.Get("x_auth_username"))
+		assert.Equal(t, "p@ss word", req.PostForm.Get("x_auth_password"))
+		// assert the form body is signed without a token
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		_, ok := params[oauthTokenParam]
+		assert.False(t, ok)
+		assertSignature(t, req, map[string]string{
+			"x_auth_mode":     "client_auth",
+			"x_auth_username": "gopher",
+			"x_auth_password": "p@ss word",
+		}, "consumer_secret", "")
+		w.Header().Set(contentType, formContentType)
+		w.Write([]byte("oauth_token=access_token&oauth_token_secret=access_secret&screen_name=gopher"))
+	})
+	defer server.Close()
+
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		Endpoint: Endpoint{
+			AccessTokenURL: server.URL + "/oauth/access_token",
+		},
+	}
+	token, err := config.XAuthAccessToken("gopher", "p@ss word")
+	assert.Nil(t, err)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, "access_token", token.Token)
+		assert.Equal(t, "access_secret", token.TokenSecret)
+		assert.Equal(t, "gopher", token.Extra("screen_name"))
+	}
+}
+
+func TestConfigXAuthAccessToken_InvalidStatus(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("invalid credentials"))
+	})
+	defer server.Close()
+
+	config := &Config{
+		Endpoint: Endpoint{
+			AccessTokenURL: server.URL,
+		},
+	}
+	token, err := config.XAuthAccessToken("gopher", "wrong")
+	assert.Nil(t, token)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: invalid status 401: invalid credentials", err.Error())
+	}
+}
+
 func TestParseAuthorizationCallback_GET(t *testing.T) {
 	expectedToken := "token"
 	expectedVerifier := "verifier"
@@ -345,3 +783,28 @@ func TestParseAuthorizationCallback_MissingTokenOrVerifier(t *testing.T) {
 	url.RawQuery = query.Encode()
 	http.Get(url.String())
 }
+
+func TestConfigParseAuthorizationCallback(t *testing.T) {
+	cases := []struct {
+		version       ProtocolVersion
+		query         string
+		expectedToken string
+		errorMsg      string
+	}{
+		{OAuth10, "oauth_token=token", "token", ""},
+		{OAuth10, "oauth_verifier=verifier", "", "oauth1: Request missing oauth_token"},
+		{OAuth10a, "oauth_token=token", "", "oauth1: Request missing oauth_token or oauth_verifier"},
+	}
+	for _, c := range cases {
+		config := &Config{ProtocolVersion: c.version}
+		req := httptest.NewRequest("GET", "https://example.com/callback?"+c.query, nil)
+		requestToken, verifier, err := config.ParseAuthorizationCallback(req)
+		assert.Equal(t, c.expectedToken, requestToken)
+		assert.Equal(t, "", verifier)
+		if c.errorMsg == "" {
+			assert.Nil(t, err)
+		} else if assert.Error(t, err) {
+			assert.Equal(t, c.errorMsg, err.Error())
+		}
+	}
+}
@@ -21,3 +21,73 @@ func contextTransport(ctx context.Context) http.RoundTripper {
 	}
 	return nil
 }
+
+type requestOptionsKey struct{}
+
+// RequestOptions override how a Transport signs a single request. Attach
+// them to a request's context with ContextWithRequestOptions so that one
+// http.Client can serve requests on behalf of many users.
+type RequestOptions struct {
+	// Token to sign the request with instead of the Transport's TokenSource
+	Token *Token
+	// Realm of authorization (overrides the Config Realm)
+	Realm string
+	// Params are extra oauth_* or xoauth_* protocol parameters to sign and
+	// send in the Authorization header
+	Params map[string]string
+	// Unsigned sends the request without an Authorization header
+	Unsigned bool
+	// RequestorID is the identity of the user on whose behalf a two-legged
+	// request is made. It is sent as the signed xoauth_requestor_id query
+	// parameter.
+	RequestorID string
+	// BodyHash signs a body which is not form encoded (e.g. XML or JSON) by
+	// sending its hash as the oauth_body_hash parameter
+	BodyHash bool
+	// SignedParams are request parameters which cannot be collected from the
+	// request (e.g. multipart/form-data text fields) to include in the
+	// signature base string
+	SignedParams map[string]string
+}
+
+// ContextWithRequestOptions returns a copy of ctx which carries the given
+// per-request signing options.
+func ContextWithRequestOptions(ctx context.Context, opts RequestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, opts)
+}
+
+// ContextWithToken returns a copy of ctx which carries an access Token to
+// sign requests with, in place of the Transport's TokenSource. Other
+// RequestOptions already carried by ctx are preserved.
+func ContextWithToken(ctx context.Context, token *Token) context.Context {
+	opts := requestOptionsFromContext(ctx)
+	opts.Token = token
+	return ContextWithRequestOptions(ctx, opts)
+}
+
+// ContextWithRequestorID returns a copy of ctx which carries the requestor
+// identity to send with two-legged requests. Other RequestOptions already
+// carried by ctx are preserved.
+func ContextWithRequestorID(ctx context.Context, requestorID string) context.Context {
+	opts := requestOptionsFromContext(ctx)
+	opts.RequestorID = requestorID
+	return ContextWithRequestOptions(ctx, opts)
+}
+
+// ContextWithBodyHash returns a copy of ctx which signs requests with the
+// oauth_body_hash of their (non-form) body. Other RequestOptions already
+// carried by ctx are preserved.
+func ContextWithBodyHash(ctx context.Context) context.Context {
+	opts := requestOptionsFromContext(ctx)
+	opts.BodyHash = true
+	return ContextWithRequestOptions(ctx, opts)
+}
+
+// requestOptionsFromContext gets the RequestOptions from the context or the
+// zero RequestOptions.
+func requestOptionsFromContext(ctx context.Context) RequestOptions {
+	if opts, ok := ctx.Value(requestOptionsKey{}).(RequestOptions); ok {
+		return opts
+	}
+	return RequestOptions{}
+}
@@ -19,3 +19,33 @@ func TestContextTransport(t *testing.T) {
 func TestContextTransport_NoContextClient(t *testing.T) {
 	assert.Nil(t, contextTransport(NoContext))
 }
+
+func TestContextWithRequestOptions(t *testing.T) {
+	opts := RequestOptions{
+		Token: NewToken("token", "secret"),
+		Realm: "photos",
+	}
+	ctx := ContextWithRequestOptions(NoContext, opts)
+	assert.Equal(t, opts, requestOptionsFromContext(ctx))
+}
+
+func TestContextWithToken(t *testing.T) {
+	ctx := ContextWithRequestOptions(NoContext, RequestOptions{Realm: "photos"})
+	ctx = ContextWithToken(ctx, NewToken("token", "secret"))
+	opts := requestOptionsFromContext(ctx)
+	// assert the token is set and other options are preserved
+	assert.Equal(t, NewToken("token", "secret"), opts.Token)
+	assert.Equal(t, "photos", opts.Realm)
+}
+
+func TestContextWithBodyHash(t *testing.T) {
+	ctx := ContextWithToken(NoContext, NewToken("token", "secret"))
+	ctx = ContextWithBodyHash(ctx)
+	opts := requestOptionsFromContext(ctx)
+	assert.True(t, opts.BodyHash)
+	assert.Equal(t, NewToken("token", "secret"), opts.Token)
+}
+
+func TestRequestOptionsFromContext_Empty(t *testing.T) {
+	assert.Equal(t, RequestOptions{}, requestOptionsFromContext(NoContext))
+}
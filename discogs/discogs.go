@@ -1,4 +1,5 @@
-// Package discogs provides constants for using OAuth1 to access Discogs.
+// Package discogs provides constants and an IdentityProvider for using OAuth1
+// to access Discogs.
 package discogs
 
 import (
@@ -10,4 +11,11 @@ var Endpoint = oauth1.Endpoint{
 	RequestTokenURL: "https://api.discogs.com/oauth/request_token",
 	AuthorizeURL:    "https://www.discogs.com/oauth/authorize",
 	AccessTokenURL:  "https://api.discogs.com/oauth/access_token",
+	Profile:         Profile,
+}
+
+// Profile is Discogs's OAuth 1.0a token request behavior. The request token
+// is requested with GET rather than POST.
+var Profile = &oauth1.Profile{
+	RequestTokenMethod: "GET",
 }
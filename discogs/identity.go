@@ -0,0 +1,42 @@
+package discogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/dghubble/oauth1"
+)
+
+const identityURL = "https://api.discogs.com/oauth/identity"
+
+// IdentityProvider looks up the Discogs user who authorized an access Token
+// with the oauth/identity API.
+type IdentityProvider struct {
+	// URL of the identity API (defaults to the Discogs API)
+	URL string
+}
+
+var _ oauth1.IdentityProvider = IdentityProvider{}
+
+type discogsIdentity struct {
+	ID       json.Number `json:"id"`
+	Username string      `json:"username"`
+}
+
+// Identity returns the user's ID and username.
+func (p IdentityProvider) Identity(ctx context.Context, config *oauth1.Config, token *oauth1.Token) (*oauth1.Identity, error) {
+	rawURL := p.URL
+	if rawURL == "" {
+		rawURL = identityURL
+	}
+	var identity discogsIdentity
+	raw, err := oauth1.GetIdentityJSON(ctx, config, token, rawURL, &identity)
+	if err != nil {
+		return nil, err
+	}
+	if identity.ID == "" {
+		return nil, errors.New("discogs: Response missing id")
+	}
+	return &oauth1.Identity{ID: identity.ID.String(), Username: identity.Username, Raw: raw}, nil
+}
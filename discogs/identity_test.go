@@ -0,0 +1,39 @@
+package discogs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/oauth1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentity(t *testing.T) {
+	cases := []struct {
+		body     string
+		identity *oauth1.Identity
+		errorMsg string
+	}{
+		{`{"id": 1, "username": "example", "resource_url": "https://api.discogs.com/users/example", "consumer_name": "Your Application Name"}`, &oauth1.Identity{ID: "1", Username: "example"}, ""},
+		{`{}`, nil, "discogs: Response missing id"},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Write([]byte(c.body))
+		}))
+		identity, err := IdentityProvider{URL: server.URL}.Identity(context.Background(), &oauth1.Config{}, oauth1.NewToken("token", "token_secret"))
+		server.Close()
+		if c.identity == nil {
+			assert.Nil(t, identity)
+			if assert.Error(t, err) {
+				assert.Equal(t, c.errorMsg, err.Error())
+			}
+			continue
+		}
+		assert.Nil(t, err)
+		c.identity.Raw = []byte(c.body)
+		assert.Equal(t, c.identity, identity)
+	}
+}
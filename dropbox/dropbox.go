@@ -1,4 +1,5 @@
-// Package dropbox provides constants for using OAuth1 to access Dropbox.
+// Package dropbox provides constants for using OAuth1 to access Dropbox and
+// a Migrator to move users with OAuth1 access tokens to OAuth2.
 package dropbox
 
 import (
@@ -10,4 +11,5 @@ var Endpoint = oauth1.Endpoint{
 	RequestTokenURL: "https://api.dropbox.com/1/oauth/request_token",
 	AuthorizeURL:    "https://api.dropbox.com/1/oauth/authorize",
 	AccessTokenURL:  "https://api.dropbox.com/1/oauth/access_token",
+	RevokeURL:       "https://api.dropbox.com/1/disable_access_token",
 }
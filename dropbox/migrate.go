@@ -0,0 +1,122 @@
+package dropbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/dghubble/oauth1"
+)
+
+const fromOAuth1URL = "https://api.dropboxapi.com/2/auth/token/from_oauth1"
+
+// Migrator converts stored Dropbox OAuth1 access tokens into OAuth2 access
+// tokens with the token/from_oauth1 API.
+type Migrator struct {
+	// Config has the Dropbox app key and secret as its consumer key and
+	// secret
+	Config *oauth1.Config
+	// HTTPClient makes migration requests (defaults to http.DefaultClient)
+	HTTPClient *http.Client
+	// RevokeOAuth1 disables the OAuth1 access token once it is converted,
+	// with Config.Revoke (the Config Endpoint RevokeURL defaults to the
+	// Dropbox Endpoint RevokeURL)
+	RevokeOAuth1 bool
+
+	// endpoint URL, overridden by tests
+	fromOAuth1URL string
+}
+
+type fromOAuth1Request struct {
+	OAuth1Token       string `json:"oauth1_token"`
+	OAuth1TokenSecret string `json:"oauth1_token_secret"`
+}
+
+type fromOAuth1Response struct {
+	OAuth2Token string `json:"oauth2_token"`
+}
+
+// Migrate converts an OAuth1 access Token into an OAuth2 access token. The
+// request is authenticated with the app key and secret. If RevokeOAuth1 is
+// set and disabling the OAuth1 token fails, the OAuth2 access token is
+// returned along with the error.
+func (m *Migrator) Migrate(ctx context.Context, token *oauth1.Token) (string, error) {
+	if m.Config == nil {
+		return "", errors.New("dropbox: Migrator Config is nil")
+	}
+	if token == nil {
+		return "", errors.New("dropbox: Token is nil")
+	}
+	body, err := json.Marshal(fromOAuth1Request{
+		OAuth1Token:       token.Token,
+		OAuth1TokenSecret: token.TokenSecret,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", m.fromOAuth1Endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(m.Config.ConsumerKey, m.Config.ConsumerSecret)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("dropbox: error reading Body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("dropbox: invalid status %d: %s", resp.StatusCode, respBody)
+	}
+	var data fromOAuth1Response
+	if err := json.Unmarshal(respBody, &data); err != nil {
+		return "", fmt.Errorf("dropbox: error decoding response: %v", err)
+	}
+	if data.OAuth2Token == "" {
+		return "", errors.New("dropbox: Response missing oauth2_token")
+	}
+	if m.RevokeOAuth1 {
+		if err := m.revoke(ctx, token); err != nil {
+			return data.OAuth2Token, err
+		}
+	}
+	return data.OAuth2Token, nil
+}
+
+// revoke disables an OAuth1 access token with Config.Revoke. A token which
+// is already revoked is not an error.
+func (m *Migrator) revoke(ctx context.Context, token *oauth1.Token) error {
+	config := *m.Config
+	if config.Endpoint.RevokeURL == "" {
+		config.Endpoint.RevokeURL = Endpoint.RevokeURL
+	}
+	if m.HTTPClient != nil {
+		config.HTTPClient = m.HTTPClient
+	}
+	if _, err := config.Revoke(ctx, token); err != nil {
+		return fmt.Errorf("dropbox: error disabling OAuth1 token: %v", err)
+	}
+	return nil
+}
+
+func (m *Migrator) fromOAuth1Endpoint() string {
+	if m.fromOAuth1URL != "" {
+		return m.fromOAuth1URL
+	}
+	return fromOAuth1URL
+}
+
+func (m *Migrator) httpClient() *http.Client {
+	if m.HTTPClient != nil {
+		return m.HTTPClient
+	}
+	return http.DefaultClient
+}
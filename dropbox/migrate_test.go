@@ -0,0 +1,127 @@
+package dropbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dghubble/oauth1"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDropboxServer returns a stand-in for the Dropbox token/from_oauth1 and
+// disable_access_token APIs.
+func newDropboxServer(t *testing.T, disabled *bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/2/auth/token/from_oauth1":
+			username, password, ok := req.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "app_key", username)
+			assert.Equal(t, "app_secret", password)
+			assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+			var data fromOAuth1Request
+			assert.Nil(t, json.NewDecoder(req.Body).Decode(&data))
+			if data.OAuth1Token != "token" || data.OAuth1TokenSecret != "token_secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error_summary": "invalid_oauth1_token_info/"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"oauth2_token": "oauth2_access_token"}`))
+		case "/1/disable_access_token":
+			assert.Equal(t, "POST", req.Method)
+			assert.True(t, strings.HasPrefix(req.Header.Get("Authorization"), "OAuth "))
+			assert.Contains(t, req.Header.Get("Authorization"), `oauth_token="token"`)
+			*disabled = true
+			w.Write([]byte(`{}`))
+		case "/1/disable_access_token/revoked":
+			w.Header().Set("WWW-Authenticate", `OAuth oauth_problem="token_revoked"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestMigrator(serverURL string) *Migrator {
+	config := oauth1.NewConfig("app_key", "app_secret")
+	config.Endpoint.RevokeURL = serverURL + "/1/disable_access_token"
+	return &Migrator{
+		Config:        config,
+		fromOAuth1URL: serverURL + "/2/auth/token/from_oauth1",
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	disabled := false
+	server := newDropboxServer(t, &disabled)
+	defer server.Close()
+
+	migrator := newTestMigrator(server.URL)
+	accessToken, err := migrator.Migrate(context.Background(), oauth1.NewToken("token", "token_secret"))
+	assert.Nil(t, err)
+	assert.Equal(t, "oauth2_access_token", accessToken)
+	assert.False(t, disabled)
+}
+
+func TestMigrate_RevokeOAuth1(t *testing.T) {
+	disabled := false
+	server := newDropboxServer(t, &disabled)
+	defer server.Close()
+
+	migrator := newTestMigrator(server.URL)
+	migrator.RevokeOAuth1 = true
+	accessToken, err := migrator.Migrate(context.Background(), oauth1.NewToken("token", "token_secret"))
+	assert.Nil(t, err)
+	assert.Equal(t, "oauth2_access_token", accessToken)
+	assert.True(t, disabled)
+}
+
+func TestMigrate_RevokeError(t *testing.T) {
+	disabled := false
+	server := newDropboxServer(t, &disabled)
+	defer server.Close()
+
+	migrator := newTestMigrator(server.URL)
+	migrator.RevokeOAuth1 = true
+	migrator.Config.Endpoint.RevokeURL = server.URL + "/1/unknown"
+	accessToken, err := migrator.Migrate(context.Background(), oauth1.NewToken("token", "token_secret"))
+	// assert the converted token is still returned
+	assert.Equal(t, "oauth2_access_token", accessToken)
+	if assert.Error(t, err) {
+		assert.Equal(t, "dropbox: error disabling OAuth1 token: oauth1: revoke failed with status 404: ", err.Error())
+	}
+}
+
+func TestMigrate_AlreadyRevoked(t *testing.T) {
+	disabled := false
+	server := newDropboxServer(t, &disabled)
+	defer server.Close()
+
+	migrator := newTestMigrator(server.URL)
+	migrator.RevokeOAuth1 = true
+	migrator.Config.Endpoint.RevokeURL = server.URL + "/1/disable_access_token/revoked"
+	accessToken, err := migrator.Migrate(context.Background(), oauth1.NewToken("token", "token_secret"))
+	// assert a token which is already revoked is not an error
+	assert.Nil(t, err)
+	assert.Equal(t, "oauth2_access_token", accessToken)
+}
+
+func TestMigrate_InvalidToken(t *testing.T) {
+	disabled := false
+	server := newDropboxServer(t, &disabled)
+	defer server.Close()
+
+	migrator := newTestMigrator(server.URL)
+	migrator.RevokeOAuth1 = true
+	accessToken, err := migrator.Migrate(context.Background(), oauth1.NewToken("token", "wrong_secret"))
+	assert.Equal(t, "", accessToken)
+	if assert.Error(t, err) {
+		assert.Equal(t, `dropbox: invalid status 400: {"error_summary": "invalid_oauth1_token_info/"}`, err.Error())
+	}
+	assert.False(t, disabled)
+}
@@ -0,0 +1,142 @@
+package oauth1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// EchoAuthorizationHeader carries the delegated OAuth Authorization
+	// header value in OAuth Echo requests.
+	EchoAuthorizationHeader = "X-Verify-Credentials-Authorization"
+	// EchoProviderHeader carries the verify credentials URL in OAuth Echo
+	// requests.
+	EchoProviderHeader = "X-Auth-Service-Provider"
+	// limit the size of provider identity responses
+	maxEchoResponseSize = 1 << 20 // 1 MB
+)
+
+// EchoHeaders returns OAuth Echo headers which delegate verification of the
+// user's Token to a third-party service. A GET request to the provider's
+// verify credentials URL (e.g. Twitter's account/verify_credentials.json) is
+// signed and its Authorization header value is returned as the
+// X-Verify-Credentials-Authorization header, along with the URL as the
+// X-Auth-Service-Provider header. Set the Config Realm if the provider
+// requires one (e.g. "http://api.twitter.com/").
+func (c *Config) EchoHeaders(token *Token, verifyURL string) (http.Header, error) {
+	if token == nil {
+		return nil, errors.New("oauth1: Token is nil")
+	}
+	req, err := http.NewRequest("GET", verifyURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = newAuther(c.authHeaderConfig()).setRequestAuthHeader(req, token)
+	if err != nil {
+		return nil, err
+	}
+	authorization := req.Header.Get(authorizationHeaderParam)
+	if authorization == "" {
+		return nil, errors.New("oauth1: OAuth Echo request has no Authorization header")
+	}
+	header := http.Header{}
+	header.Set(EchoProviderHeader, verifyURL)
+	header.Set(EchoAuthorizationHeader, authorization)
+	return header, nil
+}
+
+// authHeaderConfig returns a copy of the Config which sends protocol
+// parameters in the Authorization header, whatever the Endpoint Profile's
+// ParamPlacement, since OAuth Echo delegates the header value.
+func (c *Config) authHeaderConfig() *Config {
+	if c.profile().ParamPlacement == AuthHeader {
+		return c
+	}
+	profile := *c.profile()
+	profile.ParamPlacement = AuthHeader
+	config := *c
+	config.Endpoint.Profile = &profile
+	return &config
+}
+
+// EchoIdentity is the identity of a user verified via OAuth Echo.
+type EchoIdentity struct {
+	// Provider is the verify credentials URL which verified the user
+	Provider string
+	// Raw is the provider's response body (e.g. Twitter user JSON)
+	Raw []byte
+}
+
+// EchoVerifier verifies OAuth Echo requests received by a third-party
+// service by forwarding the delegated credentials to the provider.
+type EchoVerifier struct {
+	// AllowedProviders are the verify credentials URLs which may be called.
+	// Provider URLs must match an allowed URL's scheme, host, and path
+	AllowedProviders []string
+	// HTTPClient overrides the choice of http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// Verify forwards the OAuth Echo headers of the request to the provider
+// named by the X-Auth-Service-Provider header, which must be allowed, and
+// returns the verified identity.
+func (v *EchoVerifier) Verify(req *http.Request) (*EchoIdentity, error) {
+	provider := req.Header.Get(EchoProviderHeader)
+	authorization := req.Header.Get(EchoAuthorizationHeader)
+	if provider == "" || authorization == "" {
+		return nil, errors.New("oauth1: Request missing X-Auth-Service-Provider or X-Verify-Credentials-Authorization")
+	}
+	providerURL, err := url.Parse(provider)
+	if err != nil {
+		return nil, err
+	}
+	if !v.allowed(providerURL) {
+		return nil, fmt.Errorf("oauth1: echo provider %q is not allowed", provider)
+	}
+
+	verifyReq, err := http.NewRequestWithContext(req.Context(), "GET", provider, nil)
+	if err != nil {
+		return nil, err
+	}
+	verifyReq.Header.Set(authorizationHeaderParam, authorization)
+	resp, err := v.httpClient().Do(verifyReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxEchoResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("oauth1: error reading Body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth1: invalid status %d: %s", resp.StatusCode, body)
+	}
+	return &EchoIdentity{Provider: provider, Raw: body}, nil
+}
+
+// allowed reports whether the provider URL matches an allowed provider's
+// scheme, host, and path.
+func (v *EchoVerifier) allowed(providerURL *url.URL) bool {
+	for _, allowed := range v.AllowedProviders {
+		u, err := url.Parse(allowed)
+		if err != nil {
+			continue
+		}
+		if u.Scheme == providerURL.Scheme && u.Host == providerURL.Host && u.Path == providerURL.Path && providerURL.User == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *EchoVerifier) httpClient() *http.Client {
+	if v.HTTPClient != nil {
+		return v.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,143 @@
+package oauth1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoHeaders(t *testing.T) {
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		Realm:          "http://api.twitter.com/",
+	}
+	verifyURL := "https://api.twitter.com/1.1/account/verify_credentials.json"
+	header, err := config.EchoHeaders(NewToken("token", "secret"), verifyURL)
+	assert.Nil(t, err)
+	assert.Equal(t, verifyURL, header.Get(EchoProviderHeader))
+	params := parseOAuthParamsOrFail(t, header.Get(EchoAuthorizationHeader))
+	assert.Equal(t, "token", params[oauthTokenParam])
+	assert.Equal(t, PercentEncode("http://api.twitter.com/"), params[realmParam])
+	assert.NotEmpty(t, params[oauthSignatureParam])
+}
+
+func TestEchoHeaders_ParamPlacement(t *testing.T) {
+	for _, placement := range []ParamPlacement{FormBody, QueryString} {
+		profile := &Profile{ParamPlacement: placement}
+		config := &Config{
+			ConsumerKey:    "consumer_key",
+			ConsumerSecret: "consumer_secret",
+			Endpoint:       Endpoint{Profile: profile},
+		}
+		verifyURL := "https://api.example.com/verify"
+		header, err := config.EchoHeaders(NewToken("token", "secret"), verifyURL)
+		assert.Nil(t, err)
+		// assert the Authorization header is delegated regardless of the Profile
+		params := parseOAuthParamsOrFail(t, header.Get(EchoAuthorizationHeader))
+		assert.Equal(t, "token", params[oauthTokenParam])
+		assert.NotEmpty(t, params[oauthSignatureParam])
+		// assert the Config Profile is not modified
+		assert.Equal(t, placement, config.Endpoint.Profile.ParamPlacement)
+	}
+}
+
+func TestEchoHeaders_NilToken(t *testing.T) {
+	header, err := NewConfig("key", "secret").EchoHeaders(nil, "https://example.com")
+	assert.Nil(t, header)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Token is nil", err.Error())
+	}
+}
+
+// newEchoProviderServer returns a provider server which checks that verify
+// credentials requests are signed with the user's token.
+func newEchoProviderServer(t *testing.T) *httptest.Server {
+	return newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "GET", req.Method)
+		assert.Equal(t, "/verify_credentials.json", req.URL.Path)
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		if params[oauthTokenParam] != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assertSignature(t, req, map[string]string{}, "consumer_secret", "secret")
+		w.Write([]byte(`{"id_str": "42", "screen_name": "gopher"}`))
+	})
+}
+
+func TestEchoVerifier(t *testing.T) {
+	provider := newEchoProviderServer(t)
+	defer provider.Close()
+	verifyURL := provider.URL + "/verify_credentials.json"
+
+	// client delegates verification to a third-party service
+	config := NewConfig("consumer_key", "consumer_secret")
+	header, err := config.EchoHeaders(NewToken("token", "secret"), verifyURL)
+	assert.Nil(t, err)
+	req, err := http.NewRequest("POST", "https://media.example.com/upload", nil)
+	assert.Nil(t, err)
+	for key := range header {
+		req.Header.Set(key, header.Get(key))
+	}
+
+	verifier := &EchoVerifier{AllowedProviders: []string{verifyURL}}
+	identity, err := verifier.Verify(req)
+	assert.Nil(t, err)
+	if assert.NotNil(t, identity) {
+		assert.Equal(t, verifyURL, identity.Provider)
+		assert.JSONEq(t, `{"id_str": "42", "screen_name": "gopher"}`, string(identity.Raw))
+	}
+}
+
+func TestEchoVerifier_ProviderNotAllowed(t *testing.T) {
+	cases := []string{
+		"https://evil.example.com/verify_credentials.json",
+		"http://api.twitter.com/1.1/account/verify_credentials.json",
+		"https://api.twitter.com/1.1/statuses/update.json",
+		"https://user@api.twitter.com/1.1/account/verify_credentials.json",
+	}
+	verifier := &EchoVerifier{
+		AllowedProviders: []string{"https://api.twitter.com/1.1/account/verify_credentials.json"},
+	}
+	for _, provider := range cases {
+		req, err := http.NewRequest("GET", "https://media.example.com/upload", nil)
+		assert.Nil(t, err)
+		req.Header.Set(EchoProviderHeader, provider)
+		req.Header.Set(EchoAuthorizationHeader, `OAuth oauth_token="token"`)
+		identity, err := verifier.Verify(req)
+		assert.Nil(t, identity)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "is not allowed")
+		}
+	}
+}
+
+func TestEchoVerifier_MissingHeaders(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://media.example.com/upload", nil)
+	assert.Nil(t, err)
+	identity, err := (&EchoVerifier{}).Verify(req)
+	assert.Nil(t, identity)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Request missing X-Auth-Service-Provider or X-Verify-Credentials-Authorization", err.Error())
+	}
+}
+
+func TestEchoVerifier_Unauthorized(t *testing.T) {
+	provider := newEchoProviderServer(t)
+	defer provider.Close()
+	verifyURL := provider.URL + "/verify_credentials.json"
+
+	header, err := NewConfig("consumer_key", "consumer_secret").EchoHeaders(NewToken("revoked", "secret"), verifyURL)
+	assert.Nil(t, err)
+	req, err := http.NewRequest("GET", "https://media.example.com/upload", nil)
+	assert.Nil(t, err)
+	req.Header = header
+	identity, err := (&EchoVerifier{AllowedProviders: []string{verifyURL}}).Verify(req)
+	assert.Nil(t, identity)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "oauth1: invalid status 401")
+	}
+}
@@ -1,7 +1,7 @@
 package oauth1
 
 // Endpoint represents an OAuth1 provider's (server's) request token,
-// owner authorization, and access token request URLs.
+// owner authorization, access token, and optional revoke request URLs.
 type Endpoint struct {
 	// Request URL (Temporary Credential Request URI)
 	RequestTokenURL string
@@ -9,4 +9,95 @@ type Endpoint struct {
 	AuthorizeURL string
 	// Access Token URL (Token Request URI)
 	AccessTokenURL string
+	// Revoke URL invalidates access tokens, if the provider supports it
+	RevokeURL string
+	// Profile of the provider's protocol quirks (defaults to RFC 5849
+	// behavior)
+	Profile *Profile
+}
+
+// ParamPlacement is where the OAuth1 protocol parameters of a signed request
+// are sent.
+// See RFC 5849 3.5 Parameter Transmission.
+type ParamPlacement int
+
+const (
+	// AuthHeader sends protocol parameters in the Authorization header.
+	AuthHeader ParamPlacement = iota
+	// FormBody sends protocol parameters in the form encoded request body.
+	// Requests without a body (e.g. GET) send them in the query instead.
+	FormBody
+	// QueryString sends protocol parameters in the request URI query.
+	QueryString
+)
+
+// ResponseFormat is the encoding of a provider's token response bodies.
+type ResponseFormat int
+
+const (
+	// FormResponse token responses are form encoded.
+	FormResponse ResponseFormat = iota
+	// JSONResponse token responses are JSON objects.
+	JSONResponse
+)
+
+// Profile describes how a provider deviates from RFC 5849 when issuing
+// tokens and verifying signed requests.
+type Profile struct {
+	// RequestTokenMethod is the HTTP method of request token requests
+	// (defaults to POST)
+	RequestTokenMethod string
+	// AccessTokenMethod is the HTTP method of access token requests
+	// (defaults to POST)
+	AccessTokenMethod string
+	// ParamPlacement is where protocol parameters are sent (defaults to
+	// AuthHeader)
+	ParamPlacement ParamPlacement
+	// OmitVersion omits the optional oauth_version parameter, for providers
+	// which reject it
+	OmitVersion bool
+	// ResponseFormat of token responses (defaults to FormResponse)
+	ResponseFormat ResponseFormat
+	// SuccessStatuses are the status codes of successful token responses
+	// (defaults to 200 and 201)
+	SuccessStatuses []int
+}
+
+// defaultProfile is the RFC 5849 behavior used for Endpoints without a
+// Profile.
+var defaultProfile = &Profile{}
+
+// profile returns the Config Endpoint Profile or the default Profile.
+func (c *Config) profile() *Profile {
+	if c.Endpoint.Profile != nil {
+		return c.Endpoint.Profile
+	}
+	return defaultProfile
+}
+
+func (p *Profile) requestTokenMethod() string {
+	if p.RequestTokenMethod != "" {
+		return p.RequestTokenMethod
+	}
+	return "POST"
+}
+
+func (p *Profile) accessTokenMethod() string {
+	if p.AccessTokenMethod != "" {
+		return p.AccessTokenMethod
+	}
+	return "POST"
+}
+
+// isSuccess reports whether a token response status code is successful.
+func (p *Profile) isSuccess(statusCode int) bool {
+	if len(p.SuccessStatuses) == 0 {
+		return statusCode == 200 || statusCode == 201
+	}
+	for _, status := range p.SuccessStatuses {
+		if status == statusCode {
+			return true
+		}
+	}
+	return false
 }
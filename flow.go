@@ -0,0 +1,140 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const defaultFlowTTL = 15 * time.Minute
+
+// ErrTemporaryCredentialNotFound is returned when a request token's secret
+// is not in a TemporaryCredentialStore because it expired, was already used,
+// or was never issued.
+var ErrTemporaryCredentialNotFound = errors.New("oauth1: temporary credential not found")
+
+// TemporaryCredentialStore persists request token secrets (temporary
+// credentials) between the Begin and Complete steps of a Flow, which may run
+// in different processes.
+type TemporaryCredentialStore interface {
+	// Put stores the secret of a request token until the ttl elapses.
+	Put(ctx context.Context, requestToken, requestSecret string, ttl time.Duration) error
+	// Take returns and deletes the secret of a request token, so each
+	// temporary credential is used at most once. If the request token is
+	// not stored or expired, ErrTemporaryCredentialNotFound is returned.
+	Take(ctx context.Context, requestToken string) (requestSecret string, err error)
+}
+
+// Flow performs the three-legged OAuth1 authorization flow in two steps
+// without relying on cookies or net/http handlers. Request token secrets
+// are kept in the Store between steps.
+type Flow struct {
+	// Config of the consumer and provider Endpoint
+	Config *Config
+	// Store persists request token secrets between Begin and Complete
+	Store TemporaryCredentialStore
+	// TTL is how long a user has to authorize before Complete fails
+	// (defaults to 15 minutes)
+	TTL time.Duration
+}
+
+// Begin obtains a request token, stores its secret, and returns the
+// authorization URL to send the user to. The RequestTokenOptions may be nil.
+// Its CallbackURL is also sent on the authorization URL for OAuth10
+// providers.
+func (f *Flow) Begin(ctx context.Context, opts *RequestTokenOptions) (*url.URL, error) {
+	if f.Store == nil {
+		return nil, errors.New("oauth1: Flow Store is nil")
+	}
+	requestToken, requestSecret, err := f.Config.RequestTokenWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Store.Put(ctx, requestToken, requestSecret, f.ttl()); err != nil {
+		return nil, err
+	}
+	authorizationOpts := &AuthorizationURLOptions{}
+	if opts != nil {
+		authorizationOpts.CallbackURL = opts.CallbackURL
+	}
+	return f.Config.AuthorizationURLWithOptions(requestToken, authorizationOpts)
+}
+
+// Complete takes the request token and verifier from the authorization
+// callback (see ParseAuthorizationCallback), takes the request token secret
+// from the Store, and obtains the access Token. The temporary credential is
+// deleted even if the exchange fails.
+func (f *Flow) Complete(ctx context.Context, requestToken, verifier string) (*Token, error) {
+	if f.Store == nil {
+		return nil, errors.New("oauth1: Flow Store is nil")
+	}
+	requestSecret, err := f.Store.Take(ctx, requestToken)
+	if err != nil {
+		return nil, err
+	}
+	return f.Config.Exchange(requestToken, requestSecret, verifier)
+}
+
+func (f *Flow) ttl() time.Duration {
+	if f.TTL > 0 {
+		return f.TTL
+	}
+	return defaultFlowTTL
+}
+
+// MemoryCredentialStore is an in-memory TemporaryCredentialStore for a
+// single process. It is safe for concurrent use.
+type MemoryCredentialStore struct {
+	mu      sync.Mutex
+	secrets map[string]memoryCredential
+	clock   clock
+}
+
+type memoryCredential struct {
+	secret string
+	expiry time.Time
+}
+
+// NewMemoryCredentialStore returns a new MemoryCredentialStore.
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{secrets: map[string]memoryCredential{}}
+}
+
+// Put stores the secret of a request token until the ttl elapses. Expired
+// temporary credentials are pruned.
+func (s *MemoryCredentialStore) Put(ctx context.Context, requestToken, requestSecret string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.secrets == nil {
+		s.secrets = map[string]memoryCredential{}
+	}
+	now := s.now()
+	for token, credential := range s.secrets {
+		if !now.Before(credential.expiry) {
+			delete(s.secrets, token)
+		}
+	}
+	s.secrets[requestToken] = memoryCredential{secret: requestSecret, expiry: now.Add(ttl)}
+	return nil
+}
+
+// Take returns and deletes the secret of a request token.
+func (s *MemoryCredentialStore) Take(ctx context.Context, requestToken string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	credential, ok := s.secrets[requestToken]
+	delete(s.secrets, requestToken)
+	if !ok || !s.now().Before(credential.expiry) {
+		return "", ErrTemporaryCredentialNotFound
+	}
+	return credential.secret, nil
+}
+
+func (s *MemoryCredentialStore) now() time.Time {
+	if s.clock != nil {
+		return s.clock.Now()
+	}
+	return time.Now()
+}
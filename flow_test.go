@@ -0,0 +1,132 @@
+package oauth1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlowServer returns a test provider which issues a request token and
+// exchanges it for an access token.
+func newFlowServer(t *testing.T) *httptest.Server {
+	return newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		w.Header().Set(contentType, formContentType)
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/request_token"):
+			w.Write([]byte("oauth_token=request_token&oauth_token_secret=request_secret&oauth_callback_confirmed=true"))
+		case strings.HasSuffix(req.URL.Path, "/access_token"):
+			assert.Equal(t, "request_token", params[oauthTokenParam])
+			assert.Equal(t, "verifier", params[oauthVerifierParam])
+			w.Write([]byte("oauth_token=access_token&oauth_token_secret=access_secret"))
+		}
+	})
+}
+
+func TestFlow(t *testing.T) {
+	server := newFlowServer(t)
+	defer server.Close()
+
+	store := NewMemoryCredentialStore()
+	flow := &Flow{
+		Config: &Config{
+			Endpoint: Endpoint{
+				RequestTokenURL: server.URL + "/request_token",
+				AuthorizeURL:    server.URL + "/authorize",
+				AccessTokenURL:  server.URL + "/access_token",
+			},
+		},
+		Store: store,
+	}
+	ctx := context.Background()
+	authorizationURL, err := flow.Begin(ctx, nil)
+	assert.Nil(t, err)
+	if assert.NotNil(t, authorizationURL) {
+		assert.Equal(t, server.URL+"/authorize?oauth_token=request_token", authorizationURL.String())
+	}
+
+	token, err := flow.Complete(ctx, "request_token", "verifier")
+	assert.Nil(t, err)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, "access_token", token.Token)
+		assert.Equal(t, "access_secret", token.TokenSecret)
+	}
+
+	// assert temporary credentials are single use
+	token, err = flow.Complete(ctx, "request_token", "verifier")
+	assert.Nil(t, token)
+	assert.Equal(t, ErrTemporaryCredentialNotFound, err)
+}
+
+func TestFlow_OAuth10CallbackURL(t *testing.T) {
+	server := newFlowServer(t)
+	defer server.Close()
+
+	flow := &Flow{
+		Config: &Config{
+			CallbackURL: "https://example.com/callback",
+			Endpoint: Endpoint{
+				RequestTokenURL: server.URL + "/request_token",
+				AuthorizeURL:    server.URL + "/authorize",
+			},
+			ProtocolVersion: OAuth10,
+		},
+		Store: NewMemoryCredentialStore(),
+	}
+	opts := &RequestTokenOptions{CallbackURL: "https://example.com/callback?state=s"}
+	authorizationURL, err := flow.Begin(context.Background(), opts)
+	assert.Nil(t, err)
+	// assert the per-login callback is sent on the authorization URL
+	if assert.NotNil(t, authorizationURL) {
+		assert.Equal(t, "https://example.com/callback?state=s", authorizationURL.Query().Get(oauthCallbackParam))
+	}
+}
+
+func TestFlow_NilStore(t *testing.T) {
+	flow := &Flow{Config: &Config{}}
+	_, err := flow.Begin(context.Background(), nil)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Flow Store is nil", err.Error())
+	}
+	_, err = flow.Complete(context.Background(), "request_token", "verifier")
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Flow Store is nil", err.Error())
+	}
+}
+
+func TestMemoryCredentialStore(t *testing.T) {
+	clock := &fixedClock{time.Unix(50037133, 0)}
+	store := NewMemoryCredentialStore()
+	store.clock = clock
+	ctx := context.Background()
+	assert.Nil(t, store.Put(ctx, "token_a", "secret_a", time.Minute))
+	assert.Nil(t, store.Put(ctx, "token_b", "secret_b", 2*time.Minute))
+
+	secret, err := store.Take(ctx, "token_a")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret_a", secret)
+	_, err = store.Take(ctx, "token_a")
+	assert.Equal(t, ErrTemporaryCredentialNotFound, err)
+
+	// assert expired temporary credentials are not returned
+	clock.now = clock.now.Add(2 * time.Minute)
+	_, err = store.Take(ctx, "token_b")
+	assert.Equal(t, ErrTemporaryCredentialNotFound, err)
+	assert.Empty(t, store.secrets)
+}
+
+func TestMemoryCredentialStore_ZeroValue(t *testing.T) {
+	store := &MemoryCredentialStore{}
+	ctx := context.Background()
+	_, err := store.Take(ctx, "token")
+	assert.Equal(t, ErrTemporaryCredentialNotFound, err)
+	assert.Nil(t, store.Put(ctx, "token", "secret", time.Minute))
+	secret, err := store.Take(ctx, "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", secret)
+}
@@ -0,0 +1,57 @@
+package oauth1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// limit the size of provider identity responses
+const maxIdentityResponseSize = 1 << 20 // 1 MB
+
+// Identity is the normalized identity of the user who authorized an access
+// Token.
+type Identity struct {
+	// ID is the provider's stable user identifier
+	ID string
+	// Username is the provider's user handle or name
+	Username string
+	// Raw is the provider's response body
+	Raw []byte
+}
+
+// An IdentityProvider looks up the user who authorized an access Token with
+// a provider's "verify credentials" API.
+type IdentityProvider interface {
+	Identity(ctx context.Context, config *Config, token *Token) (*Identity, error)
+}
+
+// GetIdentityJSON GETs a provider's identity URL with a client which signs
+// requests with the access Token, decodes the JSON response body into v,
+// and returns the raw body. Bodies are read up to 1 MB. It helps implement
+// IdentityProviders.
+func GetIdentityJSON(ctx context.Context, config *Config, token *Token, rawURL string, v interface{}) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := config.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxIdentityResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("oauth1: error reading Body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oauth1: invalid status %d: %s", resp.StatusCode, body)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, fmt.Errorf("oauth1: error decoding identity: %v", err)
+	}
+	return body, nil
+}
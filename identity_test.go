@@ -0,0 +1,74 @@
+package oauth1
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIdentityJSON(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "GET", req.Method)
+		_, err := config.VerifyRequest(req, "token_secret")
+		assert.Nil(t, err)
+		w.Write([]byte(`{"id": "42"}`))
+	})
+	defer server.Close()
+
+	var data struct {
+		ID string `json:"id"`
+	}
+	raw, err := GetIdentityJSON(context.Background(), config, NewToken("token", "token_secret"), server.URL+"/me", &data)
+	assert.Nil(t, err)
+	assert.Equal(t, "42", data.ID)
+	assert.Equal(t, `{"id": "42"}`, string(raw))
+}
+
+func TestGetIdentityJSON_Errors(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/unauthorized" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Invalid token"))
+			return
+		}
+		w.Write([]byte("not json"))
+	})
+	defer server.Close()
+
+	cases := []struct {
+		path     string
+		errorMsg string
+	}{
+		{"/unauthorized", "oauth1: invalid status 401: Invalid token"},
+		{"/me", "oauth1: error decoding identity: invalid character 'o' in literal null (expecting 'u')"},
+	}
+	for _, c := range cases {
+		var data struct{}
+		raw, err := GetIdentityJSON(context.Background(), &Config{}, NewToken("token", "token_secret"), server.URL+c.path, &data)
+		assert.Nil(t, raw)
+		if assert.Error(t, err) {
+			assert.Equal(t, c.errorMsg, err.Error())
+		}
+	}
+}
+
+func TestGetIdentityJSON_Limit(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"id": "`))
+		w.Write([]byte(strings.Repeat("a", maxIdentityResponseSize)))
+		w.Write([]byte(`"}`))
+	})
+	defer server.Close()
+
+	// assert oversized bodies are truncated rather than read fully
+	var data struct{}
+	raw, err := GetIdentityJSON(context.Background(), &Config{}, NewToken("token", "token_secret"), server.URL+"/me", &data)
+	assert.Nil(t, raw)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: error decoding identity: unexpected end of JSON input", err.Error())
+	}
+}
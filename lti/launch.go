@@ -0,0 +1,71 @@
+package lti
+
+import (
+	"html/template"
+	"io"
+	"net/url"
+	"sort"
+
+	"github.com/dghubble/oauth1"
+)
+
+// SignLaunch returns the signed form parameters of a basic launch to a tool
+// provider's launch URL, as a tool consumer. The lti_message_type and
+// lti_version parameters default to a basic LTI 1.1 launch. The params
+// should include the LTI fields of the launch (e.g. resource_link_id,
+// user_id, roles).
+func SignLaunch(config *oauth1.Config, launchURL string, params url.Values) (url.Values, error) {
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = append([]string(nil), values...)
+	}
+	if form.Get(ltiMessageTypeParam) == "" {
+		form.Set(ltiMessageTypeParam, BasicLaunchRequest)
+	}
+	if form.Get(ltiVersionParam) == "" {
+		form.Set(ltiVersionParam, Version)
+	}
+	return config.SignForm("POST", launchURL, form, nil)
+}
+
+// launchFormTemplate renders a form which POSTs the launch parameters when
+// the page loads.
+var launchFormTemplate = template.Must(template.New("launch").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Launching...</title></head>
+<body onload="document.forms[0].submit()">
+<form method="POST" action="{{.URL}}" encType="application/x-www-form-urlencoded">
+{{- range .Fields}}
+<input type="hidden" name="{{.Name}}" value="{{.Value}}">
+{{- end}}
+<noscript><button type="submit">Continue</button></noscript>
+</form>
+</body>
+</html>
+`))
+
+type launchField struct {
+	Name  string
+	Value string
+}
+
+// WriteLaunchForm writes an HTML page with a form which auto-submits the
+// signed launch parameters to the tool provider's launch URL from the
+// user's browser.
+func WriteLaunchForm(w io.Writer, launchURL string, signed url.Values) error {
+	keys := make([]string, 0, len(signed))
+	for key := range signed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var fields []launchField
+	for _, key := range keys {
+		for _, value := range signed[key] {
+			fields = append(fields, launchField{Name: key, Value: value})
+		}
+	}
+	return launchFormTemplate.Execute(w, struct {
+		URL    string
+		Fields []launchField
+	}{launchURL, fields})
+}
@@ -0,0 +1,51 @@
+package lti
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+
+	"github.com/dghubble/oauth1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignLaunch(t *testing.T) {
+	config := oauth1.NewConfig("consumer_key", "consumer_secret")
+	params := url.Values{
+		"resource_link_id": {"link"},
+		"user_id":          {"42"},
+		"roles":            {"Learner"},
+	}
+	signed, err := SignLaunch(config, "https://tool.example.com/launch", params)
+	assert.Nil(t, err)
+	assert.Equal(t, BasicLaunchRequest, signed.Get("lti_message_type"))
+	assert.Equal(t, Version, signed.Get("lti_version"))
+	assert.Equal(t, "link", signed.Get("resource_link_id"))
+	assert.Equal(t, "consumer_key", signed.Get("oauth_consumer_key"))
+	assert.Equal(t, "HMAC-SHA1", signed.Get("oauth_signature_method"))
+	assert.NotEmpty(t, signed.Get("oauth_signature"))
+	// assert the given params are not modified
+	assert.Equal(t, "", params.Get("lti_message_type"))
+}
+
+func TestWriteLaunchForm(t *testing.T) {
+	signed := url.Values{
+		"oauth_signature": {"a+b/c="},
+		"resource_title":  {`"Quiz" <1>`},
+	}
+	var buf bytes.Buffer
+	err := WriteLaunchForm(&buf, "https://tool.example.com/launch?a=1&b=2", signed)
+	assert.Nil(t, err)
+	page := buf.String()
+	assert.Contains(t, page, `action="https://tool.example.com/launch?a=1&amp;b=2"`)
+	assert.Contains(t, page, `<input type="hidden" name="oauth_signature" value="a&#43;b/c=">`)
+	assert.Contains(t, page, `<input type="hidden" name="resource_title" value="&#34;Quiz&#34; &lt;1&gt;">`)
+	assert.Contains(t, page, `document.forms[0].submit()`)
+}
+
+func TestWriteLaunchForm_UnsafeURL(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteLaunchForm(&buf, "javascript:alert(1)", url.Values{})
+	assert.Nil(t, err)
+	assert.NotContains(t, buf.String(), "javascript:")
+}
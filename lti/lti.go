@@ -0,0 +1,24 @@
+// Package lti provides IMS LTI 1.1 launch signing for tool consumers and
+// launch verification for tool providers. LTI 1.1 launches are OAuth1
+// HMAC-SHA1 signed form POSTs which carry the protocol parameters in the
+// form body.
+//
+// The Basic Outcomes service (grade passback) is also supported, with an
+// OutcomesClient for tool providers and outcome request verification for
+// tool consumers. Outcome requests are XML bodies signed with an
+// oauth_body_hash.
+package lti
+
+const (
+	// BasicLaunchRequest is the lti_message_type of a basic launch.
+	BasicLaunchRequest = "basic-lti-launch-request"
+	// Version is the lti_version of LTI 1.0 and 1.1 launches.
+	Version = "LTI-1p0"
+
+	ltiMessageTypeParam = "lti_message_type"
+	ltiVersionParam     = "lti_version"
+	consumerKeyParam    = "oauth_consumer_key"
+	nonceParam          = "oauth_nonce"
+	timestampParam      = "oauth_timestamp"
+	bodyHashParam       = "oauth_body_hash"
+)
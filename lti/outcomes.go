@@ -0,0 +1,296 @@
+package lti
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/dghubble/oauth1"
+)
+
+// Basic Outcomes operations.
+const (
+	ReplaceResult = "replaceResult"
+	ReadResult    = "readResult"
+	DeleteResult  = "deleteResult"
+)
+
+const (
+	poxNamespace      = "http://www.imsglobal.org/services/ltiv1p1/xsd/imsoms_v1p0"
+	poxVersion        = "V1.0"
+	poxContentType    = "application/xml"
+	codeMajorSuccess  = "success"
+	scoreLanguage     = "en"
+	envelopeRequest   = "imsx_POXEnvelopeRequest"
+	envelopeResponse  = "imsx_POXEnvelopeResponse"
+	maxEnvelopeLength = 1 << 20 // 1 MB
+)
+
+// OutcomeError is returned when an outcome service responds with a status
+// other than success.
+type OutcomeError struct {
+	// CodeMajor is the imsx_codeMajor status (e.g. failure, unsupported)
+	CodeMajor   string
+	Description string
+}
+
+func (e *OutcomeError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("lti: outcome service status %s", e.CodeMajor)
+	}
+	return fmt.Sprintf("lti: outcome service status %s: %s", e.CodeMajor, e.Description)
+}
+
+// OutcomesClient sends Basic Outcomes requests (grade passback) to a tool
+// consumer's outcome service, as a tool provider.
+type OutcomesClient struct {
+	client *http.Client
+	noncer oauth1.Noncer
+}
+
+// NewOutcomesClient returns an OutcomesClient which signs requests
+// two-legged with the Config's consumer key and secret, including the
+// oauth_body_hash of each XML body. If ctx carries an oauth1.HTTPClient,
+// its Transport is used as the base.
+func NewOutcomesClient(ctx context.Context, config *oauth1.Config) *OutcomesClient {
+	return &OutcomesClient{
+		client: config.TwoLeggedClient(ctx),
+		noncer: oauth1.Base64Noncer{},
+	}
+}
+
+// ReplaceResult sets the score (between 0.0 and 1.0) of the result with the
+// given lis_result_sourcedid, at the lis_outcome_service_url of a launch.
+func (c *OutcomesClient) ReplaceResult(ctx context.Context, serviceURL, sourcedID string, score float64) error {
+	if score < 0 || score > 1 {
+		return fmt.Errorf("lti: score %v is not between 0.0 and 1.0", score)
+	}
+	_, err := c.do(ctx, serviceURL, &poxResultRequest{
+		SourcedID: sourcedID,
+		Score: &poxResultScore{
+			Language:   scoreLanguage,
+			TextString: strconv.FormatFloat(score, 'f', -1, 64),
+		},
+	}, ReplaceResult)
+	return err
+}
+
+// ReadResult returns the score of the result with the given
+// lis_result_sourcedid and whether the result has a score.
+func (c *OutcomesClient) ReadResult(ctx context.Context, serviceURL, sourcedID string) (float64, bool, error) {
+	resp, err := c.do(ctx, serviceURL, &poxResultRequest{SourcedID: sourcedID}, ReadResult)
+	if err != nil {
+		return 0, false, err
+	}
+	if resp.Score == nil || *resp.Score == "" {
+		return 0, false, nil
+	}
+	score, err := strconv.ParseFloat(*resp.Score, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("lti: invalid result score %q", *resp.Score)
+	}
+	return score, true, nil
+}
+
+// DeleteResult clears the score of the result with the given
+// lis_result_sourcedid.
+func (c *OutcomesClient) DeleteResult(ctx context.Context, serviceURL, sourcedID string) error {
+	_, err := c.do(ctx, serviceURL, &poxResultRequest{SourcedID: sourcedID}, DeleteResult)
+	return err
+}
+
+// do POSTs a POX request envelope for the operation to the outcome service
+// and returns the decoded response envelope.
+func (c *OutcomesClient) do(ctx context.Context, serviceURL string, result *poxResultRequest, operation string) (*poxResponse, error) {
+	envelope := &poxRequest{
+		XMLName:           xml.Name{Space: poxNamespace, Local: envelopeRequest},
+		Version:           poxVersion,
+		MessageIdentifier: c.noncer.Nonce(),
+	}
+	switch operation {
+	case ReplaceResult:
+		envelope.Replace = result
+	case ReadResult:
+		envelope.Read = result
+	case DeleteResult:
+		envelope.Delete = result
+	}
+	body, err := marshalEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(oauth1.ContextWithBodyHash(ctx), "POST", serviceURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", poxContentType)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lti: outcome service returned status %d", resp.StatusCode)
+	}
+	envelopeResp := new(poxResponse)
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxEnvelopeLength)).Decode(envelopeResp); err != nil {
+		return nil, fmt.Errorf("lti: invalid outcome response: %v", err)
+	}
+	if envelopeResp.XMLName.Local != envelopeResponse {
+		return nil, fmt.Errorf("lti: invalid outcome response element %q", envelopeResp.XMLName.Local)
+	}
+	if envelopeResp.CodeMajor != codeMajorSuccess {
+		return nil, &OutcomeError{CodeMajor: envelopeResp.CodeMajor, Description: envelopeResp.Description}
+	}
+	return envelopeResp, nil
+}
+
+// OutcomeRequest is a Basic Outcomes request received by a tool consumer's
+// outcome service.
+type OutcomeRequest struct {
+	// ConsumerKey of the tool provider which sent the request
+	ConsumerKey       string
+	MessageIdentifier string
+	// Operation is ReplaceResult, ReadResult, or DeleteResult
+	Operation string
+	// SourcedID is the lis_result_sourcedid of the result
+	SourcedID string
+	// Score of a ReplaceResult request
+	Score float64
+}
+
+// ParseOutcomeRequest parses a Basic Outcomes POX request envelope. It does
+// not verify the request, see Verifier VerifyOutcome.
+func ParseOutcomeRequest(r io.Reader) (*OutcomeRequest, error) {
+	envelope := new(poxRequest)
+	if err := xml.NewDecoder(io.LimitReader(r, maxEnvelopeLength)).Decode(envelope); err != nil {
+		return nil, fmt.Errorf("lti: invalid outcome request: %v", err)
+	}
+	if envelope.XMLName.Local != envelopeRequest {
+		return nil, fmt.Errorf("lti: invalid outcome request element %q", envelope.XMLName.Local)
+	}
+	outcome := &OutcomeRequest{MessageIdentifier: envelope.MessageIdentifier}
+	var result *poxResultRequest
+	switch {
+	case envelope.Replace != nil:
+		outcome.Operation, result = ReplaceResult, envelope.Replace
+	case envelope.Read != nil:
+		outcome.Operation, result = ReadResult, envelope.Read
+	case envelope.Delete != nil:
+		outcome.Operation, result = DeleteResult, envelope.Delete
+	default:
+		return nil, errors.New("lti: unsupported outcome request operation")
+	}
+	if result.SourcedID == "" {
+		return nil, errors.New("lti: outcome request missing sourcedId")
+	}
+	outcome.SourcedID = result.SourcedID
+	if outcome.Operation == ReplaceResult {
+		if result.Score == nil {
+			return nil, errors.New("lti: replaceResult request missing resultScore")
+		}
+		score, err := strconv.ParseFloat(result.Score.TextString, 64)
+		if err != nil || score < 0 || score > 1 {
+			return nil, fmt.Errorf("lti: invalid result score %q", result.Score.TextString)
+		}
+		outcome.Score = score
+	}
+	return outcome, nil
+}
+
+// OutcomeResponse is an outcome service's response to an OutcomeRequest.
+type OutcomeResponse struct {
+	// CodeMajor is the imsx_codeMajor status (success, failure, processing,
+	// or unsupported)
+	CodeMajor   string
+	Description string
+	// Score of the result for a ReadResult response, or nil if the result
+	// has no score
+	Score *float64
+}
+
+// WriteOutcomeResponse writes the POX response envelope to an
+// OutcomeRequest.
+func WriteOutcomeResponse(w http.ResponseWriter, req *OutcomeRequest, resp *OutcomeResponse) error {
+	envelope := &poxResponse{
+		XMLName:           xml.Name{Space: poxNamespace, Local: envelopeResponse},
+		Version:           poxVersion,
+		MessageIdentifier: req.MessageIdentifier,
+		CodeMajor:         resp.CodeMajor,
+		Severity:          "status",
+		Description:       resp.Description,
+		MessageRef:        req.MessageIdentifier,
+		OperationRef:      req.Operation,
+	}
+	switch req.Operation {
+	case ReplaceResult:
+		envelope.Replace = &struct{}{}
+	case ReadResult:
+		score := ""
+		if resp.Score != nil {
+			score = strconv.FormatFloat(*resp.Score, 'f', -1, 64)
+		}
+		envelope.Score = &score
+		envelope.ScoreLanguage = scoreLanguage
+	case DeleteResult:
+		envelope.Delete = &struct{}{}
+	}
+	body, err := marshalEnvelope(envelope)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", poxContentType)
+	_, err = w.Write(body)
+	return err
+}
+
+// marshalEnvelope returns the XML document of a POX envelope.
+func marshalEnvelope(envelope interface{}) ([]byte, error) {
+	b, err := xml.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// poxRequest is a Basic Outcomes imsx_POXEnvelopeRequest. The XMLName is
+// not tagged so envelopes are decoded regardless of namespace.
+type poxRequest struct {
+	XMLName           xml.Name
+	Version           string            `xml:"imsx_POXHeader>imsx_POXRequestHeaderInfo>imsx_version"`
+	MessageIdentifier string            `xml:"imsx_POXHeader>imsx_POXRequestHeaderInfo>imsx_messageIdentifier"`
+	Replace           *poxResultRequest `xml:"imsx_POXBody>replaceResultRequest,omitempty"`
+	Read              *poxResultRequest `xml:"imsx_POXBody>readResultRequest,omitempty"`
+	Delete            *poxResultRequest `xml:"imsx_POXBody>deleteResultRequest,omitempty"`
+}
+
+type poxResultRequest struct {
+	SourcedID string          `xml:"resultRecord>sourcedGUID>sourcedId"`
+	Score     *poxResultScore `xml:"resultRecord>result>resultScore,omitempty"`
+}
+
+type poxResultScore struct {
+	Language   string `xml:"language"`
+	TextString string `xml:"textString"`
+}
+
+// poxResponse is a Basic Outcomes imsx_POXEnvelopeResponse.
+type poxResponse struct {
+	XMLName           xml.Name
+	Version           string    `xml:"imsx_POXHeader>imsx_POXResponseHeaderInfo>imsx_version"`
+	MessageIdentifier string    `xml:"imsx_POXHeader>imsx_POXResponseHeaderInfo>imsx_messageIdentifier"`
+	CodeMajor         string    `xml:"imsx_POXHeader>imsx_POXResponseHeaderInfo>imsx_statusInfo>imsx_codeMajor"`
+	Severity          string    `xml:"imsx_POXHeader>imsx_POXResponseHeaderInfo>imsx_statusInfo>imsx_severity"`
+	Description       string    `xml:"imsx_POXHeader>imsx_POXResponseHeaderInfo>imsx_statusInfo>imsx_description"`
+	MessageRef        string    `xml:"imsx_POXHeader>imsx_POXResponseHeaderInfo>imsx_statusInfo>imsx_messageRefIdentifier"`
+	OperationRef      string    `xml:"imsx_POXHeader>imsx_POXResponseHeaderInfo>imsx_statusInfo>imsx_operationRefIdentifier"`
+	Replace           *struct{} `xml:"imsx_POXBody>replaceResultResponse,omitempty"`
+	ScoreLanguage     string    `xml:"imsx_POXBody>readResultResponse>result>resultScore>language,omitempty"`
+	Score             *string   `xml:"imsx_POXBody>readResultResponse>result>resultScore>textString,omitempty"`
+	Delete            *struct{} `xml:"imsx_POXBody>deleteResultResponse,omitempty"`
+}
@@ -0,0 +1,166 @@
+package lti
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dghubble/oauth1"
+	"github.com/stretchr/testify/assert"
+)
+
+// newOutcomeService returns a test outcome service which stores scores by
+// sourcedId.
+func newOutcomeService(t *testing.T, scores map[string]float64) *httptest.Server {
+	verifier := &Verifier{Secret: testSecret}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		outcome, err := verifier.VerifyOutcome(req)
+		if !assert.Nil(t, err) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "consumer_key", outcome.ConsumerKey)
+		assert.NotEmpty(t, outcome.MessageIdentifier)
+		resp := &OutcomeResponse{CodeMajor: "success"}
+		switch outcome.Operation {
+		case ReplaceResult:
+			scores[outcome.SourcedID] = outcome.Score
+		case ReadResult:
+			if score, ok := scores[outcome.SourcedID]; ok {
+				resp.Score = &score
+			}
+		case DeleteResult:
+			delete(scores, outcome.SourcedID)
+		}
+		assert.Nil(t, WriteOutcomeResponse(w, outcome, resp))
+	}))
+}
+
+func TestOutcomesClient(t *testing.T) {
+	scores := map[string]float64{}
+	server := newOutcomeService(t, scores)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := NewOutcomesClient(ctx, oauth1.NewConfig("consumer_key", "consumer_secret"))
+	err := client.ReplaceResult(ctx, server.URL+"/outcomes", "sourced-1", 0.92)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{"sourced-1": 0.92}, scores)
+
+	score, ok, err := client.ReadResult(ctx, server.URL+"/outcomes", "sourced-1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0.92, score)
+
+	err = client.DeleteResult(ctx, server.URL+"/outcomes", "sourced-1")
+	assert.Nil(t, err)
+	assert.Empty(t, scores)
+
+	score, ok, err = client.ReadResult(ctx, server.URL+"/outcomes", "sourced-1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, score)
+}
+
+func TestOutcomesClient_InvalidScore(t *testing.T) {
+	client := NewOutcomesClient(context.Background(), oauth1.NewConfig("consumer_key", "consumer_secret"))
+	err := client.ReplaceResult(context.Background(), "https://lms.example.com/outcomes", "sourced-1", 1.5)
+	if assert.Error(t, err) {
+		assert.Equal(t, "lti: score 1.5 is not between 0.0 and 1.0", err.Error())
+	}
+}
+
+func TestOutcomesClient_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		outcome, err := ParseOutcomeRequest(req.Body)
+		assert.Nil(t, err)
+		WriteOutcomeResponse(w, outcome, &OutcomeResponse{CodeMajor: "failure", Description: "sourcedId not found"})
+	}))
+	defer server.Close()
+
+	client := NewOutcomesClient(context.Background(), oauth1.NewConfig("consumer_key", "consumer_secret"))
+	err := client.DeleteResult(context.Background(), server.URL, "unknown")
+	assert.Equal(t, &OutcomeError{CodeMajor: "failure", Description: "sourcedId not found"}, err)
+	assert.Equal(t, "lti: outcome service status failure: sourcedId not found", err.Error())
+}
+
+func TestVerifyOutcome_Invalid(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<imsx_POXEnvelopeRequest xmlns="http://www.imsglobal.org/services/ltiv1p1/xsd/imsoms_v1p0">
+  <imsx_POXHeader><imsx_POXRequestHeaderInfo><imsx_version>V1.0</imsx_version><imsx_messageIdentifier>1</imsx_messageIdentifier></imsx_POXRequestHeaderInfo></imsx_POXHeader>
+  <imsx_POXBody><deleteResultRequest><resultRecord><sourcedGUID><sourcedId>sourced-1</sourcedId></sourcedGUID></resultRecord></deleteResultRequest></imsx_POXBody>
+</imsx_POXEnvelopeRequest>`
+	errs := make(chan error, 1)
+	verifier := &Verifier{Secret: testSecret}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/tampered" {
+			req.Body = http.NoBody
+		}
+		_, err := verifier.VerifyOutcome(req)
+		errs <- err
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		path     string
+		ctx      context.Context
+		errorMsg string
+	}{
+		{"/", context.Background(), "lti: Request missing oauth_body_hash"},
+		{"/tampered", oauth1.ContextWithBodyHash(context.Background()), "oauth1: invalid body hash"},
+	}
+	client := oauth1.NewConfig("consumer_key", "consumer_secret").TwoLeggedClient(context.Background())
+	for _, c := range cases {
+		req, err := http.NewRequestWithContext(c.ctx, "POST", server.URL+c.path, strings.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Set("Content-Type", "application/xml")
+		_, err = client.Do(req)
+		assert.Nil(t, err)
+		err = <-errs
+		if assert.Error(t, err) {
+			assert.Equal(t, c.errorMsg, err.Error())
+		}
+	}
+}
+
+func TestParseOutcomeRequest(t *testing.T) {
+	envelope := func(body string) string {
+		return `<imsx_POXEnvelopeRequest><imsx_POXHeader><imsx_POXRequestHeaderInfo>` +
+			`<imsx_version>V1.0</imsx_version><imsx_messageIdentifier>999</imsx_messageIdentifier>` +
+			`</imsx_POXRequestHeaderInfo></imsx_POXHeader><imsx_POXBody>` + body + `</imsx_POXBody></imsx_POXEnvelopeRequest>`
+	}
+	record := func(sourcedID, score string) string {
+		r := `<resultRecord><sourcedGUID><sourcedId>` + sourcedID + `</sourcedId></sourcedGUID>`
+		if score != "" {
+			r += `<result><resultScore><language>en</language><textString>` + score + `</textString></resultScore></result>`
+		}
+		return r + `</resultRecord>`
+	}
+	outcome, err := ParseOutcomeRequest(strings.NewReader(envelope(`<replaceResultRequest>` + record("sourced-1", "0.5") + `</replaceResultRequest>`)))
+	assert.Nil(t, err)
+	assert.Equal(t, &OutcomeRequest{MessageIdentifier: "999", Operation: ReplaceResult, SourcedID: "sourced-1", Score: 0.5}, outcome)
+
+	outcome, err = ParseOutcomeRequest(strings.NewReader(envelope(`<readResultRequest>` + record("sourced-1", "") + `</readResultRequest>`)))
+	assert.Nil(t, err)
+	assert.Equal(t, &OutcomeRequest{MessageIdentifier: "999", Operation: ReadResult, SourcedID: "sourced-1"}, outcome)
+
+	cases := []struct {
+		body     string
+		errorMsg string
+	}{
+		{envelope(`<replaceResultRequest>` + record("sourced-1", "1.1") + `</replaceResultRequest>`), `lti: invalid result score "1.1"`},
+		{envelope(`<replaceResultRequest>` + record("sourced-1", "") + `</replaceResultRequest>`), "lti: replaceResult request missing resultScore"},
+		{envelope(`<readResultRequest>` + record("", "") + `</readResultRequest>`), "lti: outcome request missing sourcedId"},
+		{envelope(`<readMembershipRequest/>`), "lti: unsupported outcome request operation"},
+		{`<imsx_POXEnvelopeResponse/>`, `lti: invalid outcome request element "imsx_POXEnvelopeResponse"`},
+	}
+	for _, c := range cases {
+		outcome, err := ParseOutcomeRequest(strings.NewReader(c.body))
+		assert.Nil(t, outcome)
+		if assert.Error(t, err) {
+			assert.Equal(t, c.errorMsg, err.Error())
+		}
+	}
+}
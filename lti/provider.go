@@ -0,0 +1,194 @@
+package lti
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dghubble/oauth1"
+)
+
+const defaultMaxSkew = 5 * time.Minute
+
+// A NonceStore records the nonces of verified launches so replayed launches
+// can be rejected.
+type NonceStore interface {
+	// Use records the nonce of a consumer until the expiry and returns
+	// false if the nonce was already used.
+	Use(consumerKey, nonce string, expiry time.Time) bool
+}
+
+// Verifier verifies basic launch requests received by a tool provider and
+// Basic Outcomes requests received by a tool consumer.
+type Verifier struct {
+	// Secret returns the shared secret of a consumer key, or false if the
+	// consumer is unknown
+	Secret func(consumerKey string) (string, bool)
+	// Nonces records launch nonces (defaults to an in-memory NonceStore)
+	Nonces NonceStore
+	// MaxSkew is the maximum difference between a launch's oauth_timestamp
+	// and the current time (defaults to 5 minutes)
+	MaxSkew time.Duration
+
+	once   sync.Once
+	nonces NonceStore
+	clock  func() time.Time
+}
+
+// Verify verifies the OAuth1 signature, timestamp, and nonce of a launch
+// request and returns the launch form parameters. Launch requests received
+// behind a TLS terminating proxy should have req.URL.Scheme set to the
+// scheme the tool consumer used.
+func (v *Verifier) Verify(req *http.Request) (url.Values, error) {
+	if v.Secret == nil {
+		return nil, errors.New("lti: Verifier Secret func is nil")
+	}
+	if req.Method != "POST" {
+		return nil, errors.New("lti: launch request must be a POST")
+	}
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	consumerKey := req.PostForm.Get(consumerKeyParam)
+	secret, ok := v.Secret(consumerKey)
+	if !ok {
+		return nil, errors.New("lti: unknown oauth_consumer_key")
+	}
+	config := oauth1.NewConfig(consumerKey, secret)
+	params, err := config.VerifyRequest(req, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkReplay(consumerKey, params); err != nil {
+		return nil, err
+	}
+	if req.PostForm.Get(ltiMessageTypeParam) == "" || req.PostForm.Get(ltiVersionParam) == "" {
+		return nil, errors.New("lti: Request missing lti_message_type or lti_version")
+	}
+	return req.PostForm, nil
+}
+
+// VerifyOutcome verifies the OAuth1 signature, body hash, timestamp, and
+// nonce of a Basic Outcomes request received by a tool consumer's outcome
+// service and returns the parsed request.
+func (v *Verifier) VerifyOutcome(req *http.Request) (*OutcomeRequest, error) {
+	if v.Secret == nil {
+		return nil, errors.New("lti: Verifier Secret func is nil")
+	}
+	if req.Method != "POST" {
+		return nil, errors.New("lti: outcome request must be a POST")
+	}
+	oauthParams, err := oauth1.RequestOAuthParams(req)
+	if err != nil {
+		return nil, err
+	}
+	consumerKey := oauthParams[consumerKeyParam]
+	secret, ok := v.Secret(consumerKey)
+	if !ok {
+		return nil, errors.New("lti: unknown oauth_consumer_key")
+	}
+	if _, ok := oauthParams[bodyHashParam]; !ok {
+		return nil, errors.New("lti: Request missing oauth_body_hash")
+	}
+	config := oauth1.NewConfig(consumerKey, secret)
+	params, err := config.VerifyRequest(req, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := v.checkReplay(consumerKey, params); err != nil {
+		return nil, err
+	}
+	outcome, err := ParseOutcomeRequest(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	outcome.ConsumerKey = consumerKey
+	return outcome, nil
+}
+
+// checkReplay checks the oauth_timestamp of a verified request is within
+// the allowed window and records its oauth_nonce, rejecting replays.
+func (v *Verifier) checkReplay(consumerKey string, params map[string]string) error {
+	now := v.now()
+	timestamp, err := strconv.ParseInt(params[timestampParam], 10, 64)
+	if err != nil {
+		return errors.New("lti: invalid oauth_timestamp")
+	}
+	issued := time.Unix(timestamp, 0)
+	if issued.Before(now.Add(-v.maxSkew())) || issued.After(now.Add(v.maxSkew())) {
+		return errors.New("lti: oauth_timestamp is outside the allowed window")
+	}
+	nonce := params[nonceParam]
+	if nonce == "" {
+		return errors.New("lti: Request missing oauth_nonce")
+	}
+	if !v.nonceStore().Use(consumerKey, nonce, issued.Add(v.maxSkew())) {
+		return errors.New("lti: oauth_nonce was already used")
+	}
+	return nil
+}
+
+func (v *Verifier) nonceStore() NonceStore {
+	if v.Nonces != nil {
+		return v.Nonces
+	}
+	v.once.Do(func() {
+		v.nonces = NewMemoryNonceStore()
+	})
+	return v.nonces
+}
+
+func (v *Verifier) maxSkew() time.Duration {
+	if v.MaxSkew > 0 {
+		return v.MaxSkew
+	}
+	return defaultMaxSkew
+}
+
+func (v *Verifier) now() time.Time {
+	if v.clock != nil {
+		return v.clock()
+	}
+	return time.Now()
+}
+
+// MemoryNonceStore is an in-memory NonceStore for a single tool provider
+// process. It is safe for concurrent use.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	clock  func() time.Time
+}
+
+// NewMemoryNonceStore returns a new MemoryNonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: map[string]time.Time{}}
+}
+
+// Use records the nonce of a consumer until the expiry and returns false if
+// the nonce was already used. Expired nonces are pruned.
+func (s *MemoryNonceStore) Use(consumerKey, nonce string, expiry time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nonces == nil {
+		s.nonces = map[string]time.Time{}
+	}
+	now := time.Now()
+	if s.clock != nil {
+		now = s.clock()
+	}
+	for key, exp := range s.nonces {
+		if exp.Before(now) {
+			delete(s.nonces, key)
+		}
+	}
+	key := consumerKey + "&" + nonce
+	if _, ok := s.nonces[key]; ok {
+		return false
+	}
+	s.nonces[key] = expiry
+	return true
+}
@@ -0,0 +1,122 @@
+package lti
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dghubble/oauth1"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSecret(consumerKey string) (string, bool) {
+	if consumerKey == "consumer_key" {
+		return "consumer_secret", true
+	}
+	return "", false
+}
+
+// newLaunchRequest returns a launch request received by a tool provider.
+func newLaunchRequest(t *testing.T, signed url.Values) *http.Request {
+	req := httptest.NewRequest("POST", "https://tool.example.com/launch", strings.NewReader(signed.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func signTestLaunch(t *testing.T, consumerKey string) url.Values {
+	config := oauth1.NewConfig(consumerKey, "consumer_secret")
+	signed, err := SignLaunch(config, "https://tool.example.com/launch", url.Values{
+		"resource_link_id": {"link"},
+		"user_id":          {"42"},
+	})
+	assert.Nil(t, err)
+	return signed
+}
+
+func TestVerifier(t *testing.T) {
+	verifier := &Verifier{Secret: testSecret}
+	signed := signTestLaunch(t, "consumer_key")
+	params, err := verifier.Verify(newLaunchRequest(t, signed))
+	assert.Nil(t, err)
+	assert.Equal(t, "link", params.Get("resource_link_id"))
+	assert.Equal(t, "42", params.Get("user_id"))
+
+	// assert replayed launches are rejected
+	params, err = verifier.Verify(newLaunchRequest(t, signed))
+	assert.Nil(t, params)
+	if assert.Error(t, err) {
+		assert.Equal(t, "lti: oauth_nonce was already used", err.Error())
+	}
+}
+
+func TestVerifier_Invalid(t *testing.T) {
+	tampered := signTestLaunch(t, "consumer_key")
+	tampered.Set("roles", "Instructor")
+	missingLTI := signTestLaunch(t, "consumer_key")
+	missingLTI.Del("lti_version")
+	cases := []struct {
+		signed   url.Values
+		errorMsg string
+	}{
+		{signTestLaunch(t, "unknown_key"), "lti: unknown oauth_consumer_key"},
+		{tampered, "oauth1: invalid signature"},
+		{missingLTI, "oauth1: invalid signature"},
+	}
+	for _, c := range cases {
+		verifier := &Verifier{Secret: testSecret}
+		params, err := verifier.Verify(newLaunchRequest(t, c.signed))
+		assert.Nil(t, params)
+		if assert.Error(t, err) {
+			assert.Equal(t, c.errorMsg, err.Error())
+		}
+	}
+}
+
+func TestVerifier_Timestamp(t *testing.T) {
+	signed := signTestLaunch(t, "consumer_key")
+	cases := []time.Duration{-10 * time.Minute, 10 * time.Minute}
+	for _, offset := range cases {
+		verifier := &Verifier{
+			Secret: testSecret,
+			clock: func() time.Time {
+				return time.Now().Add(offset)
+			},
+		}
+		params, err := verifier.Verify(newLaunchRequest(t, signed))
+		assert.Nil(t, params)
+		if assert.Error(t, err) {
+			assert.Equal(t, "lti: oauth_timestamp is outside the allowed window", err.Error())
+		}
+	}
+}
+
+func TestVerifier_NotPost(t *testing.T) {
+	verifier := &Verifier{Secret: testSecret}
+	params, err := verifier.Verify(httptest.NewRequest("GET", "https://tool.example.com/launch", nil))
+	assert.Nil(t, params)
+	if assert.Error(t, err) {
+		assert.Equal(t, "lti: launch request must be a POST", err.Error())
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	store := NewMemoryNonceStore()
+	store.clock = func() time.Time { return now }
+	assert.True(t, store.Use("key", "nonce", now.Add(time.Minute)))
+	assert.False(t, store.Use("key", "nonce", now.Add(time.Minute)))
+	// nonces are scoped to consumers
+	assert.True(t, store.Use("other", "nonce", now.Add(time.Minute)))
+	// expired nonces are pruned
+	now = now.Add(2 * time.Minute)
+	assert.True(t, store.Use("key", "nonce", now.Add(time.Minute)))
+}
+
+func TestMemoryNonceStore_ZeroValue(t *testing.T) {
+	store := &MemoryNonceStore{}
+	assert.True(t, store.Use("key", "nonce", time.Now().Add(time.Minute)))
+	assert.False(t, store.Use("key", "nonce", time.Now().Add(time.Minute)))
+}
@@ -0,0 +1,127 @@
+package oauth1
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const defaultFileContentType = "application/octet-stream"
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// MultipartFile is a file part of a multipart/form-data request body.
+type MultipartFile struct {
+	// FieldName is the form field name of the file part
+	FieldName string
+	// FileName is the file name sent with the file part
+	FileName string
+	// ContentType of the file (defaults to application/octet-stream)
+	ContentType string
+	// Open returns a reader over the file contents. Open is called each time
+	// the request body is sent, so requests may be retried or redirected
+	Open func() (io.ReadCloser, error)
+}
+
+// NewMultipartRequest returns a new multipart/form-data request (e.g. for
+// Twitter or Tumblr media uploads) with the given text fields and files. The
+// body is streamed so file contents are not buffered into memory. The
+// request should be sent or its Body closed to release the streaming writer.
+//
+// Per RFC 5849 3.4.1.3.1, multipart parts are not signed by a Transport.
+// Text fields named in signedFields, which some providers expect to be
+// signed, are included in the signature base string via the request
+// context's RequestOptions.
+func NewMultipartRequest(ctx context.Context, method, rawURL string, fields url.Values, files []MultipartFile, signedFields ...string) (*http.Request, error) {
+	opts := requestOptionsFromContext(ctx)
+	if len(signedFields) > 0 {
+		signedParams := map[string]string{}
+		for key, value := range opts.SignedParams {
+			signedParams[key] = value
+		}
+		for _, field := range signedFields {
+			if _, ok := fields[field]; !ok {
+				return nil, fmt.Errorf("oauth1: signed field %q is not a form field", field)
+			}
+			signedParams[field] = fields.Get(field)
+		}
+		opts.SignedParams = signedParams
+		ctx = ContextWithRequestOptions(ctx, opts)
+	}
+
+	boundary := multipart.NewWriter(nil).Boundary()
+	getBody := func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(writeMultipart(pw, boundary, fields, files))
+		}()
+		return pr, nil
+	}
+	body, _ := getBody()
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.GetBody = getBody
+	req.Header.Set(contentType, "multipart/form-data; boundary="+boundary)
+	return req, nil
+}
+
+// writeMultipart writes sorted text fields and then files as a
+// multipart/form-data body with the given boundary.
+func writeMultipart(w io.Writer, boundary string, fields url.Values, files []MultipartFile) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range fields[key] {
+			if err := mw.WriteField(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range files {
+		if err := writeMultipartFile(mw, file); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// writeMultipartFile streams a file part to the multipart writer.
+func writeMultipartFile(mw *multipart.Writer, file MultipartFile) error {
+	if file.Open == nil {
+		return fmt.Errorf("oauth1: multipart file %q has no Open func", file.FieldName)
+	}
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = defaultFileContentType
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(file.FieldName), quoteEscaper.Replace(file.FileName)))
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(part, rc)
+	return err
+}
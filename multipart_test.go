@@ -0,0 +1,108 @@
+package oauth1
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func openString(s string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(s)), nil
+	}
+}
+
+func TestNewMultipartRequest(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Nil(t, req.ParseMultipartForm(1<<20))
+		assert.Equal(t, "caption text", req.FormValue("caption"))
+		assert.Equal(t, "photo", req.FormValue("type"))
+		file, header, err := req.FormFile("media")
+		if assert.Nil(t, err) {
+			defer file.Close()
+			assert.Equal(t, "cat.jpg", header.Filename)
+			assert.Equal(t, "image/jpeg", header.Header.Get("Content-Type"))
+			b, _ := ioutil.ReadAll(file)
+			assert.Equal(t, "jpeg bytes", string(b))
+		}
+
+		// assert only the signed field is included in the signature
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		signature, err := url.QueryUnescape(params[oauthSignatureParam])
+		assert.Nil(t, err)
+		delete(params, oauthSignatureParam)
+		params["type"] = "photo"
+		req.URL.Scheme = "http"
+		req.URL.Host = req.Host
+		expected, err := (&HMACSigner{ConsumerSecret: "consumer_secret"}).Sign("token_secret", signatureBase(req, params))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, signature)
+	})
+	defer server.Close()
+
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		Noncer:         &fixedNoncer{"some_nonce"},
+	}
+	client := config.Client(NoContext, NewToken("token", "token_secret"))
+	fields := url.Values{
+		"caption": {"caption text"},
+		"type":    {"photo"},
+	}
+	files := []MultipartFile{
+		{FieldName: "media", FileName: "cat.jpg", ContentType: "image/jpeg", Open: openString("jpeg bytes")},
+	}
+	req, err := NewMultipartRequest(context.Background(), "POST", server.URL+"/upload", fields, files, "type")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(req.Header.Get(contentType), "multipart/form-data; boundary="))
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestNewMultipartRequest_GetBody(t *testing.T) {
+	files := []MultipartFile{
+		{FieldName: "media", FileName: "a.txt", Open: openString("contents")},
+	}
+	req, err := NewMultipartRequest(context.Background(), "POST", "https://example.com", url.Values{"k": {"v"}}, files)
+	assert.Nil(t, err)
+	first, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	// assert the body can be replayed
+	body, err := req.GetBody()
+	assert.Nil(t, err)
+	second, err := ioutil.ReadAll(body)
+	assert.Nil(t, err)
+	assert.Equal(t, string(first), string(second))
+	assert.Contains(t, string(first), "Content-Type: application/octet-stream")
+	assert.Contains(t, string(first), "contents")
+	// assert no parameters are signed
+	assert.Nil(t, requestOptionsFromContext(req.Context()).SignedParams)
+}
+
+func TestNewMultipartRequest_UnknownSignedField(t *testing.T) {
+	req, err := NewMultipartRequest(context.Background(), "POST", "https://example.com", url.Values{}, nil, "status")
+	assert.Nil(t, req)
+	if assert.Error(t, err) {
+		assert.Equal(t, `oauth1: signed field "status" is not a form field`, err.Error())
+	}
+}
+
+func TestNewMultipartRequest_OpenError(t *testing.T) {
+	files := []MultipartFile{
+		{FieldName: "media", FileName: "a.txt", Open: func() (io.ReadCloser, error) {
+			return nil, io.ErrUnexpectedEOF
+		}},
+	}
+	req, err := NewMultipartRequest(context.Background(), "POST", "https://example.com", url.Values{}, files)
+	assert.Nil(t, err)
+	_, err = ioutil.ReadAll(req.Body)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,107 @@
+package oauth1
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	oauthProblemParam       = "oauth_problem"
+	wwwAuthenticateHeader   = "WWW-Authenticate"
+	twitterInvalidTokenCode = 89
+	// maxProblemBodySize limits the bytes of a 401 response body read to
+	// classify it
+	maxProblemBodySize = 64 << 10
+)
+
+// TokenInvalidError is returned by a Transport when a provider responds 401
+// Unauthorized because the access token is invalid (e.g. the user revoked
+// access or the token expired). The token should be marked invalid and
+// requests with it should not be retried.
+type TokenInvalidError struct {
+	// Token the request was signed with
+	Token *Token
+	// StatusCode of the provider response
+	StatusCode int
+	// Problem is the oauth_problem reported by the provider, if any
+	Problem string
+	// Body of the provider response (truncated to 64KB)
+	Body []byte
+}
+
+func (e *TokenInvalidError) Error() string {
+	if e.Problem != "" {
+		return fmt.Sprintf("oauth1: access token is invalid: %s", e.Problem)
+	}
+	return "oauth1: access token is invalid"
+}
+
+// OAuth Problem Reporting extension oauth_problem values which mean the
+// access token can no longer be used.
+var invalidTokenProblems = map[string]bool{
+	"token_used":     true,
+	"token_expired":  true,
+	"token_revoked":  true,
+	"token_rejected": true,
+}
+
+// parseOAuthProblem returns the oauth_problem of a provider response from
+// the WWW-Authenticate header or the form encoded body, according to the
+// OAuth Problem Reporting extension, or "" if there is none.
+func parseOAuthProblem(header http.Header, body []byte) string {
+	for _, value := range header.Values(wwwAuthenticateHeader) {
+		if len(value) < len(authorizationPrefix) || !strings.EqualFold(value[:len(authorizationPrefix)], authorizationPrefix) {
+			continue
+		}
+		if params, err := parseAuthHeader(value); err == nil && params[oauthProblemParam] != "" {
+			return params[oauthProblemParam]
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get(contentType))
+	if mediaType == formContentType || mediaType == "text/plain" || mediaType == "text/html" {
+		if values, err := url.ParseQuery(strings.TrimSpace(string(body))); err == nil {
+			return values.Get(oauthProblemParam)
+		}
+	}
+	return ""
+}
+
+// twitterErrorCodes returns the codes of a Twitter API JSON error response
+// body (e.g. {"errors": [{"code": 89, "message": "..."}]}).
+func twitterErrorCodes(body []byte) []int {
+	var data struct {
+		Errors []struct {
+			Code int `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil
+	}
+	codes := make([]int, len(data.Errors))
+	for i, e := range data.Errors {
+		codes[i] = e.Code
+	}
+	return codes
+}
+
+// isInvalidTokenResponse reports whether a 401 Unauthorized provider
+// response means the access token is invalid (e.g. revoked or expired), as
+// shown by an oauth_problem or a Twitter "Invalid or expired token" error.
+func isInvalidTokenResponse(statusCode int, problem string, body []byte) bool {
+	if statusCode != http.StatusUnauthorized {
+		return false
+	}
+	if invalidTokenProblems[problem] {
+		return true
+	}
+	for _, code := range twitterErrorCodes(body) {
+		if code == twitterInvalidTokenCode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package oauth1
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOAuthProblem(t *testing.T) {
+	cases := []struct {
+		header   http.Header
+		body     string
+		expected string
+	}{
+		{http.Header{http.CanonicalHeaderKey(wwwAuthenticateHeader): {`OAuth realm="photos", oauth_problem="token_rejected"`}}, "", "token_rejected"},
+		// non-OAuth challenges are skipped
+		{http.Header{http.CanonicalHeaderKey(wwwAuthenticateHeader): {`Basic realm="api"`, `OAuth oauth_problem="nonce_used"`}}, "", "nonce_used"},
+		{http.Header{contentType: {formContentType}}, "oauth_problem=timestamp_refused&oauth_acceptable_timestamps=1-2\n", "timestamp_refused"},
+		{http.Header{contentType: {"text/plain; charset=utf-8"}}, "oauth_problem=token_used", "token_used"},
+		// JSON bodies do not carry an oauth_problem
+		{http.Header{contentType: {"application/json"}}, `{"oauth_problem": "token_used"}`, ""},
+		{http.Header{}, "", ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, parseOAuthProblem(c.header, []byte(c.body)))
+	}
+}
+
+func TestIsInvalidTokenResponse(t *testing.T) {
+	cases := []struct {
+		statusCode int
+		problem    string
+		body       string
+		expected   bool
+	}{
+		{401, "token_revoked", "", true},
+		{401, "token_expired", "", true},
+		{401, "", `{"errors": [{"code": 32}, {"code": 89}]}`, true},
+		{401, "signature_invalid", "", false},
+		{401, "", `{"errors": [{"code": 32}]}`, false},
+		{403, "token_revoked", "", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, isInvalidTokenResponse(c.statusCode, c.problem, []byte(c.body)))
+	}
+}
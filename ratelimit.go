@@ -0,0 +1,261 @@
+package oauth1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Discogs uses a moving one minute window rather than a reset time.
+const discogsRateLimitWindow = time.Minute
+
+// RateLimit is a provider's request budget, as reported by rate limit
+// response headers.
+type RateLimit struct {
+	// Limit is the number of requests allowed per window (0 if unknown)
+	Limit int
+	// Remaining is the number of requests remaining in the window
+	Remaining int
+	// Reset is when the window resets (zero if unknown)
+	Reset time.Time
+}
+
+// RateLimitError is returned when a request is rejected because its rate
+// limit budget is exhausted.
+type RateLimitError struct {
+	// Key identifies the rate limited budget
+	Key string
+	// Reset is when the budget resets
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("oauth1: rate limit exceeded until %s", e.Reset.Format(time.RFC3339))
+}
+
+// RateLimitTransport is an http.RoundTripper which tracks provider rate limit
+// headers (Twitter x-rate-limit-*, Tumblr X-Ratelimit-*, Discogs
+// X-Discogs-Ratelimit-*, and Retry-After) and pauses or rejects requests
+// whose budget is exhausted, rather than sending them to fail with a 429. It
+// should wrap a Transport, so requests are signed after any wait.
+type RateLimitTransport struct {
+	// Base is the RoundTripper which signs and sends requests (e.g. a
+	// Transport). If nil, then http.DefaultTransport is used
+	Base http.RoundTripper
+	// Key returns the key of the budget a request draws from (defaults to
+	// DefaultRateLimitKey, a budget per Token and endpoint). Use
+	// TokenRateLimitKey for providers whose limits are per Token (e.g.
+	// Discogs)
+	Key func(req *http.Request) string
+	// Reject returns a *RateLimitError instead of waiting for the budget
+	// to reset
+	Reject bool
+	// MaxWait is the longest wait before rejecting a request (defaults to no
+	// maximum)
+	MaxWait time.Duration
+
+	mu     sync.Mutex
+	limits map[string]RateLimit
+	clock  clock
+}
+
+// RateLimit returns the current budget for the given key, if known.
+func (t *RateLimitTransport) RateLimit(key string) (RateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limit, ok := t.limits[key]
+	return limit, ok
+}
+
+// RoundTrip waits for (or rejects) requests whose budget is exhausted, sends
+// the request via the Base RoundTripper, and records the budget reported by
+// the response headers.
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.key(req)
+	for {
+		wait := t.reserve(key)
+		if wait <= 0 {
+			break
+		}
+		if t.Reject || (t.MaxWait > 0 && wait > t.MaxWait) {
+			return nil, &RateLimitError{Key: key, Reset: t.now().Add(wait)}
+		}
+		if !sleepContext(req.Context(), wait) {
+			return nil, req.Context().Err()
+		}
+	}
+	resp, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if limit, ok := parseRateLimit(resp, t.now()); ok {
+		t.mu.Lock()
+		t.limits[key] = limit
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// reserve draws a request from the budget for the key and returns zero, or
+// returns the wait until the exhausted budget resets.
+func (t *RateLimitTransport) reserve(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limits == nil {
+		t.limits = map[string]RateLimit{}
+	}
+	limit, ok := t.limits[key]
+	if !ok {
+		return 0
+	}
+	now := t.now()
+	if limit.Remaining > 0 {
+		// count in-flight requests against the budget
+		limit.Remaining--
+		t.limits[key] = limit
+		return 0
+	}
+	if limit.Reset.After(now) {
+		return limit.Reset.Sub(now)
+	}
+	// the window has reset, but the new budget is unknown
+	delete(t.limits, key)
+	return 0
+}
+
+func (t *RateLimitTransport) key(req *http.Request) string {
+	if t.Key != nil {
+		return t.Key(req)
+	}
+	return DefaultRateLimitKey(req)
+}
+
+// DefaultRateLimitKey keys rate limit budgets by the Token set by
+// ContextWithToken and the request host and path (e.g.
+// "token api.twitter.com/1.1/statuses/update.json"), matching providers
+// such as Twitter which limit each Token per endpoint. Requests without a
+// Token are keyed by host and path alone.
+func DefaultRateLimitKey(req *http.Request) string {
+	endpoint := req.URL.Host + req.URL.Path
+	if token := TokenRateLimitKey(req); token != "" {
+		return token + " " + endpoint
+	}
+	return endpoint
+}
+
+// TokenRateLimitKey keys rate limit budgets by the Token set by
+// ContextWithToken, or "" for requests without one, for providers such as
+// Discogs which limit each Token across all endpoints.
+func TokenRateLimitKey(req *http.Request) string {
+	if token := requestOptionsFromContext(req.Context()).Token; token != nil {
+		return token.Token
+	}
+	return ""
+}
+
+func (t *RateLimitTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *RateLimitTransport) now() time.Time {
+	if t.clock != nil {
+		return t.clock.Now()
+	}
+	return time.Now()
+}
+
+// parseRateLimit parses the rate limit budget reported by a response's
+// headers. A Retry-After header on a 429 or 503 response exhausts the budget
+// until the given time.
+func parseRateLimit(resp *http.Response, now time.Time) (RateLimit, bool) {
+	header := resp.Header
+	limit, ok := parseTwitterRateLimit(header)
+	if !ok {
+		limit, ok = parseTumblrRateLimit(header, now)
+	}
+	if !ok {
+		limit, ok = parseDiscogsRateLimit(header, now)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if retryAfter, found := parseRetryAfter(header.Get("Retry-After"), now); found {
+			limit.Remaining = 0
+			limit.Reset = retryAfter
+			ok = true
+		}
+	}
+	return limit, ok
+}
+
+// parseTwitterRateLimit parses x-rate-limit-* headers, where the reset is
+// given in Unix epoch seconds.
+func parseTwitterRateLimit(header http.Header) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(header.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	limit := RateLimit{Remaining: remaining}
+	limit.Limit, _ = strconv.Atoi(header.Get("X-Rate-Limit-Limit"))
+	if reset, err := strconv.ParseInt(header.Get("X-Rate-Limit-Reset"), 10, 64); err == nil {
+		limit.Reset = time.Unix(reset, 0)
+	}
+	return limit, true
+}
+
+// parseTumblrRateLimit parses the X-Ratelimit-Perday-* and
+// X-Ratelimit-Perhour-* headers, where resets are given in seconds from now,
+// and returns the more constrained budget.
+func parseTumblrRateLimit(header http.Header, now time.Time) (RateLimit, bool) {
+	var limit RateLimit
+	found := false
+	for _, window := range []string{"Perday", "Perhour"} {
+		remaining, err := strconv.Atoi(header.Get("X-Ratelimit-" + window + "-Remaining"))
+		if err != nil {
+			continue
+		}
+		if found && remaining >= limit.Remaining {
+			continue
+		}
+		limit = RateLimit{Remaining: remaining}
+		limit.Limit, _ = strconv.Atoi(header.Get("X-Ratelimit-" + window + "-Limit"))
+		if reset, err := strconv.Atoi(header.Get("X-Ratelimit-" + window + "-Reset")); err == nil {
+			limit.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+		found = true
+	}
+	return limit, found
+}
+
+// parseDiscogsRateLimit parses X-Discogs-Ratelimit-* headers. Discogs uses a
+// moving window, so an exhausted budget is assumed to reset after a minute.
+func parseDiscogsRateLimit(header http.Header, now time.Time) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(header.Get("X-Discogs-Ratelimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	limit := RateLimit{Remaining: remaining}
+	limit.Limit, _ = strconv.Atoi(header.Get("X-Discogs-Ratelimit"))
+	if remaining <= 0 {
+		limit.Reset = now.Add(discogsRateLimitWindow)
+	}
+	return limit, true
+}
+
+// parseRetryAfter parses a Retry-After header value given in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,245 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	cases := []struct {
+		status int
+		header map[string]string
+		limit  RateLimit
+		ok     bool
+	}{
+		{200, map[string]string{}, RateLimit{}, false},
+		// Twitter
+		{200, map[string]string{
+			"x-rate-limit-limit":     "15",
+			"x-rate-limit-remaining": "14",
+			"x-rate-limit-reset":     "1500000900",
+		}, RateLimit{Limit: 15, Remaining: 14, Reset: time.Unix(1500000900, 0)}, true},
+		// Tumblr reports the more constrained of its windows
+		{200, map[string]string{
+			"X-Ratelimit-Perday-Limit":      "5000",
+			"X-Ratelimit-Perday-Remaining":  "4000",
+			"X-Ratelimit-Perday-Reset":      "80000",
+			"X-Ratelimit-Perhour-Limit":     "1000",
+			"X-Ratelimit-Perhour-Remaining": "10",
+			"X-Ratelimit-Perhour-Reset":     "600",
+		}, RateLimit{Limit: 1000, Remaining: 10, Reset: now.Add(600 * time.Second)}, true},
+		// Discogs
+		{200, map[string]string{
+			"X-Discogs-Ratelimit":           "60",
+			"X-Discogs-Ratelimit-Remaining": "59",
+		}, RateLimit{Limit: 60, Remaining: 59}, true},
+		{200, map[string]string{
+			"X-Discogs-Ratelimit":           "60",
+			"X-Discogs-Ratelimit-Remaining": "0",
+		}, RateLimit{Limit: 60, Remaining: 0, Reset: now.Add(time.Minute)}, true},
+		// Retry-After
+		{429, map[string]string{"Retry-After": "120"}, RateLimit{Reset: now.Add(120 * time.Second)}, true},
+		{503, map[string]string{"Retry-After": "Fri, 14 Jul 2017 02:45:00 GMT"}, RateLimit{Reset: time.Date(2017, 7, 14, 2, 45, 0, 0, time.UTC)}, true},
+		{429, map[string]string{
+			"x-rate-limit-limit":     "15",
+			"x-rate-limit-remaining": "3",
+			"Retry-After":            "60",
+		}, RateLimit{Limit: 15, Remaining: 0, Reset: now.Add(time.Minute)}, true},
+		{200, map[string]string{"Retry-After": "120"}, RateLimit{}, false},
+	}
+	for _, c := range cases {
+		resp := &http.Response{StatusCode: c.status, Header: http.Header{}}
+		for key, value := range c.header {
+			resp.Header.Set(key, value)
+		}
+		limit, ok := parseRateLimit(resp, now)
+		assert.Equal(t, c.ok, ok)
+		assert.True(t, c.limit.Reset.Equal(limit.Reset), "expected reset %v, got %v", c.limit.Reset, limit.Reset)
+		limit.Reset = c.limit.Reset
+		assert.Equal(t, c.limit, limit)
+	}
+}
+
+// newRateLimitServer returns a server which reports the given remaining
+// budget and a reset an hour from now and counts requests.
+func newRateLimitServer(remaining int, requests *int) *httptest.Server {
+	return newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		*requests++
+		w.Header().Set("x-rate-limit-remaining", strconv.Itoa(remaining))
+		w.Header().Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	})
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	requests := 0
+	server := newRateLimitServer(7, &requests)
+	defer server.Close()
+
+	tr := &RateLimitTransport{}
+	client := &http.Client{Transport: tr}
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	// assert the budget was recorded for the endpoint
+	limit, ok := tr.RateLimit(strings.TrimPrefix(server.URL, "http://"))
+	assert.True(t, ok)
+	assert.Equal(t, 7, limit.Remaining)
+}
+
+func TestRateLimitTransport_reject(t *testing.T) {
+	requests := 0
+	server := newRateLimitServer(0, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: &RateLimitTransport{Reject: true}}
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	// assert the request is rejected before reaching the server
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, resp)
+	var rateLimitErr *RateLimitError
+	if assert.True(t, errors.As(err, &rateLimitErr)) {
+		assert.True(t, rateLimitErr.Reset.After(time.Now()))
+	}
+	assert.Equal(t, 1, requests)
+}
+
+func TestRateLimitTransport_maxWait(t *testing.T) {
+	requests := 0
+	server := newRateLimitServer(0, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: &RateLimitTransport{MaxWait: time.Minute}}
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	_, err = client.Get(server.URL)
+	var rateLimitErr *RateLimitError
+	assert.True(t, errors.As(err, &rateLimitErr))
+	assert.Equal(t, 1, requests)
+}
+
+func TestRateLimitTransport_wait(t *testing.T) {
+	requests := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	})
+	defer server.Close()
+
+	tr := &RateLimitTransport{
+		limits: map[string]RateLimit{
+			strings.TrimPrefix(server.URL, "http://"): {Remaining: 0, Reset: time.Now().Add(50 * time.Millisecond)},
+		},
+	}
+	client := &http.Client{Transport: tr}
+	start := time.Now()
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	// assert the request waited for the budget to reset
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+	assert.Equal(t, 1, requests)
+}
+
+func TestRateLimitTransport_waitContextCanceled(t *testing.T) {
+	requests := 0
+	server := newRateLimitServer(0, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: &RateLimitTransport{}}
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	assert.Nil(t, err)
+	_, err = client.Do(req)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 1, requests)
+}
+
+func TestRateLimitTransport_perToken(t *testing.T) {
+	requests := 0
+	server := newRateLimitServer(0, &requests)
+	defer server.Close()
+
+	tr := &RateLimitTransport{Reject: true}
+	client := &http.Client{Transport: tr}
+	for _, token := range []string{"alice", "bob"} {
+		ctx := ContextWithToken(context.Background(), NewToken(token, "secret"))
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+		assert.Nil(t, err)
+		_, err = client.Do(req)
+		assert.Nil(t, err)
+	}
+	// assert each token has its own budget
+	assert.Equal(t, 2, requests)
+	host := strings.TrimPrefix(server.URL, "http://")
+	_, ok := tr.RateLimit("alice " + host)
+	assert.True(t, ok)
+	_, ok = tr.RateLimit("bob " + host)
+	assert.True(t, ok)
+}
+
+func TestRateLimitTransport_perEndpoint(t *testing.T) {
+	requests := 0
+	server := newRateLimitServer(0, &requests)
+	defer server.Close()
+
+	client := &http.Client{Transport: &RateLimitTransport{Reject: true}}
+	ctx := ContextWithToken(context.Background(), NewToken("alice", "secret"))
+	for _, path := range []string{"/statuses/update.json", "/statuses/home_timeline.json"} {
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL+path, nil)
+		assert.Nil(t, err)
+		_, err = client.Do(req)
+		assert.Nil(t, err)
+	}
+	// assert an exhausted endpoint does not block the Token's other endpoints
+	assert.Equal(t, 2, requests)
+}
+
+func TestRateLimitTransport_tokenKey(t *testing.T) {
+	requests := 0
+	server := newRateLimitServer(0, &requests)
+	defer server.Close()
+
+	tr := &RateLimitTransport{Key: TokenRateLimitKey, Reject: true}
+	client := &http.Client{Transport: tr}
+	ctx := ContextWithToken(context.Background(), NewToken("alice", "secret"))
+	for _, path := range []string{"/database/search", "/users/alice"} {
+		req, err := http.NewRequestWithContext(ctx, "GET", server.URL+path, nil)
+		assert.Nil(t, err)
+		client.Do(req)
+	}
+	// assert the Token's budget is shared across endpoints
+	assert.Equal(t, 1, requests)
+	_, ok := tr.RateLimit("alice")
+	assert.True(t, ok)
+}
+
+func TestRateLimitTransport_counts(t *testing.T) {
+	requests := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+	})
+	defer server.Close()
+
+	tr := &RateLimitTransport{
+		Reject: true,
+		limits: map[string]RateLimit{
+			strings.TrimPrefix(server.URL, "http://"): {Remaining: 2, Reset: time.Now().Add(time.Hour)},
+		},
+	}
+	client := &http.Client{Transport: tr}
+	for i := 0; i < 3; i++ {
+		client.Get(server.URL)
+	}
+	// assert requests without rate limit headers draw down the known budget
+	assert.Equal(t, 2, requests)
+}
@@ -39,7 +39,7 @@ func TestTwitterRequestTokenAuthHeader(t *testing.T) {
 	auther := &auther{config, &fixedClock{time.Unix(unixTimestamp, 0)}}
 	req, err := http.NewRequest("POST", config.Endpoint.RequestTokenURL, nil)
 	assert.Nil(t, err)
-	err = auther.setRequestTokenAuthHeader(req)
+	err = auther.setRequestTokenAuthHeader(req, config.CallbackURL)
 	// assert the request for a request token is signed and has an oauth_callback
 	assert.Nil(t, err)
 	params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
@@ -120,7 +120,7 @@ func TestTwitterParameterString(t *testing.T) {
 	req.Header.Set(contentType, formContentType)
 	oauthParams := auther.commonOAuthParams()
 	oauthParams[oauthTokenParam] = expectedTwitterOAuthToken
-	params, err := collectParameters(req, oauthParams)
+	params, err := collectParameters(req, oauthParams, defaultMaxFormBodySize)
 	// assert that the parameter string matches the reference
 	expectedParameterString := "include_entities=true&oauth_consumer_key=xvz1evFS4wEEPTGEFPHBog&oauth_nonce=kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg&oauth_signature_method=HMAC-SHA1&oauth_timestamp=1318622958&oauth_token=370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb&oauth_version=1.0&status=Hello%20Ladies%20%2B%20Gentlemen%2C%20a%20signed%20OAuth%20request%21"
 	assert.Nil(t, err)
@@ -137,7 +137,7 @@ func TestTwitterSignatureBase(t *testing.T) {
 	req.Header.Set(contentType, formContentType)
 	oauthParams := auther.commonOAuthParams()
 	oauthParams[oauthTokenParam] = expectedTwitterOAuthToken
-	params, err := collectParameters(req, oauthParams)
+	params, err := collectParameters(req, oauthParams, defaultMaxFormBodySize)
 	signatureBase := signatureBase(req, params)
 	// assert that the signature base string matches the reference
 	// checks that method is uppercased, url is encoded, parameter string is added, all joined by &
@@ -155,7 +155,7 @@ func TestTwitterRequestAuthHeader(t *testing.T) {
 	values := url.Values{}
 	values.Add("status", "Hello Ladies + Gentlemen, a signed OAuth request!")
 
-	accessToken := &Token{expectedTwitterOAuthToken, oauthTokenSecret}
+	accessToken := &Token{Token: expectedTwitterOAuthToken, TokenSecret: oauthTokenSecret}
 	req, err := http.NewRequest("POST", "https://api.twitter.com/1/statuses/update.json?include_entities=true", strings.NewReader(values.Encode()))
 	assert.Nil(t, err)
 	req.Header.Set(contentType, formContentType)
@@ -188,6 +188,29 @@ func parseOAuthParamsOrFail(t *testing.T, authHeader string) map[string]string {
 	return params
 }
 
+// assertSignature asserts that a request received by a test server is
+// signed by the given secrets, over its Authorization header parameters and
+// the given request parameters.
+func assertSignature(t *testing.T, req *http.Request, params map[string]string, consumerSecret, tokenSecret string) {
+	oauthParams := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+	signature, err := url.QueryUnescape(oauthParams[oauthSignatureParam])
+	assert.Nil(t, err)
+	for key, value := range oauthParams {
+		if key != oauthSignatureParam && key != realmParam {
+			value, err = url.QueryUnescape(value)
+			assert.Nil(t, err)
+			params[key] = value
+		}
+	}
+	u := *req.URL
+	u.Scheme = "http"
+	u.Host = req.Host
+	signed := &http.Request{Method: req.Method, URL: &u}
+	expected, err := (&HMACSigner{ConsumerSecret: consumerSecret}).Sign(tokenSecret, signatureBase(signed, params))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, signature)
+}
+
 type fixedClock struct {
 	now time.Time
 }
@@ -0,0 +1,231 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	loginPath    = "login"
+	callbackPath = "callback"
+	// loginCookiePrefix prefixes the name of the per-provider cookie which
+	// binds a callback to the browser which started the login
+	loginCookiePrefix = "oauth1_login_"
+)
+
+// Registry maps provider names (e.g. "twitter") to Configs and serves each
+// provider's login and callback endpoints from one http.Handler, at
+// "/{provider}/login" and "/{provider}/callback". Mount it with
+// http.StripPrefix to serve it under a path prefix. Each Config's
+// CallbackURL should be the provider's callback endpoint.
+//
+// Logins are bound to the browser which started them with an HttpOnly
+// cookie per provider, so a callback URL from another user's login (e.g. an attacker
+// linking their provider account to a victim's session) is rejected.
+type Registry struct {
+	// Store persists request token secrets between login and callback
+	Store TemporaryCredentialStore
+	// TTL is how long a user has to authorize (defaults to 15 minutes)
+	TTL time.Duration
+	// OnSuccess is called with the provider name, access Token, and token
+	// response parameters (e.g. user_id, screen_name) when a callback
+	// completes. It should write the response (e.g. set a session and
+	// redirect).
+	OnSuccess func(w http.ResponseWriter, req *http.Request, provider string, token *Token, extras url.Values)
+	// OnError is called when a login or callback fails (defaults to
+	// responding 400 Bad Request, or 404 Not Found for unknown providers)
+	OnError func(w http.ResponseWriter, req *http.Request, provider string, err error)
+	// CookiePath is the path of the login cookie, which must include the
+	// callback endpoints (defaults to "/")
+	CookiePath string
+	// InsecureCookie omits the Secure attribute of the login cookie, for
+	// development over plain HTTP
+	InsecureCookie bool
+
+	mu      sync.RWMutex
+	configs map[string]*Config
+}
+
+// ErrUnknownProvider is passed to the Registry OnError func when a request
+// is for a provider which is not registered.
+var ErrUnknownProvider = errors.New("oauth1: unknown provider")
+
+// ErrLoginCookieMissing is passed to the Registry OnError func when a
+// callback request does not have the login cookie set by the login
+// endpoint, so it was not started by the same browser.
+var ErrLoginCookieMissing = errors.New("oauth1: callback missing login cookie")
+
+// NewRegistry returns a new Registry which keeps request token secrets in
+// the given TemporaryCredentialStore.
+func NewRegistry(store TemporaryCredentialStore) *Registry {
+	return &Registry{Store: store}
+}
+
+// Register adds or replaces the Config of a provider name. Names may not
+// contain "/".
+func (r *Registry) Register(provider string, config *Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.configs == nil {
+		r.configs = map[string]*Config{}
+	}
+	r.configs[provider] = config
+}
+
+// Config returns the Config of a provider name.
+func (r *Registry) Config(provider string) (*Config, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	config, ok := r.configs[provider]
+	return config, ok
+}
+
+// Providers returns the sorted names of the registered providers.
+func (r *Registry) Providers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	providers := make([]string, 0, len(r.configs))
+	for provider := range r.configs {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// ServeHTTP routes "/{provider}/login" requests to the provider's
+// authorization page and completes "/{provider}/callback" requests.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 2 || (parts[1] != loginPath && parts[1] != callbackPath) {
+		http.NotFound(w, req)
+		return
+	}
+	provider := parts[0]
+	config, ok := r.Config(provider)
+	if !ok {
+		r.error(w, req, provider, ErrUnknownProvider)
+		return
+	}
+	if parts[1] == loginPath {
+		r.login(w, req, provider, config)
+		return
+	}
+	r.callback(w, req, provider, config)
+}
+
+// flow returns a Flow for the provider whose temporary credentials are
+// keyed by the login binding value.
+func (r *Registry) flow(provider, binding string, config *Config) *Flow {
+	flow := &Flow{Config: config, TTL: r.TTL}
+	if r.Store != nil {
+		flow.Store = &providerStore{provider: provider, binding: binding, store: r.Store}
+	}
+	return flow
+}
+
+// login begins the provider's Flow, binds it to the browser with a login
+// cookie, and redirects to the authorization page.
+func (r *Registry) login(w http.ResponseWriter, req *http.Request, provider string, config *Config) {
+	binding := HexNoncer{}.Nonce()
+	flow := r.flow(provider, binding, config)
+	authorizationURL, err := flow.Begin(req.Context(), nil)
+	if err != nil {
+		r.error(w, req, provider, err)
+		return
+	}
+	http.SetCookie(w, r.loginCookie(provider, binding, int(flow.ttl().Seconds())))
+	http.Redirect(w, req, authorizationURL.String(), http.StatusFound)
+}
+
+// callback completes the provider's Flow started by the same browser and
+// calls OnSuccess.
+func (r *Registry) callback(w http.ResponseWriter, req *http.Request, provider string, config *Config) {
+	if r.OnSuccess == nil {
+		r.error(w, req, provider, errors.New("oauth1: Registry OnSuccess func is nil"))
+		return
+	}
+	cookie, err := req.Cookie(loginCookieName(provider))
+	if err != nil || cookie.Value == "" {
+		r.error(w, req, provider, ErrLoginCookieMissing)
+		return
+	}
+	flow := r.flow(provider, cookie.Value, config)
+	requestToken, verifier, err := flow.Config.ParseAuthorizationCallback(req)
+	if err != nil {
+		r.error(w, req, provider, err)
+		return
+	}
+	token, err := flow.Complete(req.Context(), requestToken, verifier)
+	if err != nil {
+		r.error(w, req, provider, err)
+		return
+	}
+	// the login cookie is single use, like the temporary credential, but is
+	// kept until the login completes so an invalid callback doesn't end it
+	http.SetCookie(w, r.loginCookie(provider, "", -1))
+	r.OnSuccess(w, req, provider, token, token.extraValues())
+}
+
+// loginCookie returns the provider's login cookie with the given binding
+// value and max age in seconds.
+func (r *Registry) loginCookie(provider, binding string, maxAge int) *http.Cookie {
+	path := r.CookiePath
+	if path == "" {
+		path = "/"
+	}
+	return &http.Cookie{
+		Name:     loginCookieName(provider),
+		Value:    binding,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   !r.InsecureCookie,
+		// Lax so the cookie is sent on the provider's redirect to the callback
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// loginCookieName returns the name of a provider's login cookie, so logins
+// to several providers can be in progress at once.
+func loginCookieName(provider string) string {
+	return loginCookiePrefix + url.QueryEscape(provider)
+}
+
+func (r *Registry) error(w http.ResponseWriter, req *http.Request, provider string, err error) {
+	if r.OnError != nil {
+		r.OnError(w, req, provider, err)
+		return
+	}
+	if err == ErrUnknownProvider {
+		http.NotFound(w, req)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+}
+
+// providerStore scopes a TemporaryCredentialStore to a provider and a login
+// binding value, so request tokens issued by one provider cannot be
+// completed with another, or by a browser which did not start the login.
+type providerStore struct {
+	provider string
+	binding  string
+	store    TemporaryCredentialStore
+}
+
+func (s *providerStore) Put(ctx context.Context, requestToken, requestSecret string, ttl time.Duration) error {
+	return s.store.Put(ctx, s.key(requestToken), requestSecret, ttl)
+}
+
+func (s *providerStore) Take(ctx context.Context, requestToken string) (string, error) {
+	return s.store.Take(ctx, s.key(requestToken))
+}
+
+func (s *providerStore) key(requestToken string) string {
+	return s.provider + "/" + s.binding + "/" + requestToken
+}
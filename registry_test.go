@@ -0,0 +1,217 @@
+package oauth1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRegistryProvider returns a test provider which issues the given request
+// token and exchanges it for an access token.
+func newRegistryProvider(t *testing.T, requestToken string) *httptest.Server {
+	return newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(contentType, formContentType)
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/request_token"):
+			w.Write([]byte("oauth_token=" + requestToken + "&oauth_token_secret=request_secret&oauth_callback_confirmed=true"))
+		case strings.HasSuffix(req.URL.Path, "/access_token"):
+			w.Write([]byte("oauth_token=access_token&oauth_token_secret=access_secret&screen_name=gopher"))
+		}
+	})
+}
+
+func newRegistryConfig(providerURL, callbackURL string) *Config {
+	return &Config{
+		CallbackURL: callbackURL,
+		Endpoint: Endpoint{
+			RequestTokenURL: providerURL + "/request_token",
+			AuthorizeURL:    providerURL + "/authorize",
+			AccessTokenURL:  providerURL + "/access_token",
+		},
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	twitter := newRegistryProvider(t, "twitter_token")
+	defer twitter.Close()
+	tumblr := newRegistryProvider(t, "tumblr_token")
+	defer tumblr.Close()
+
+	called := false
+	registry := NewRegistry(NewMemoryCredentialStore())
+	registry.Register("twitter", newRegistryConfig(twitter.URL, "https://example.com/auth/twitter/callback"))
+	registry.Register("tumblr", newRegistryConfig(tumblr.URL, "https://example.com/auth/tumblr/callback"))
+	registry.OnSuccess = func(w http.ResponseWriter, req *http.Request, provider string, token *Token, extras url.Values) {
+		called = true
+		assert.Equal(t, "tumblr", provider)
+		assert.Equal(t, "access_token", token.Token)
+		assert.Equal(t, "access_secret", token.TokenSecret)
+		assert.Equal(t, "gopher", extras.Get("screen_name"))
+		w.WriteHeader(http.StatusNoContent)
+	}
+	assert.Equal(t, []string{"tumblr", "twitter"}, registry.Providers())
+	handler := http.StripPrefix("/auth", registry)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/auth/tumblr/login", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, tumblr.URL+"/authorize?oauth_token=tumblr_token", w.Header().Get("Location"))
+	cookie := loginCookieOrFail(t, w, "tumblr")
+
+	// assert request tokens cannot be completed with another provider
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/auth/twitter/callback?oauth_token=tumblr_token&oauth_verifier=verifier", nil)
+	req.AddCookie(cookie)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, called)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/auth/tumblr/callback?oauth_token=tumblr_token&oauth_verifier=verifier", nil)
+	req.AddCookie(cookie)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, called)
+	// assert the login cookie is cleared
+	if cookies := w.Result().Cookies(); assert.Len(t, cookies, 1) {
+		assert.Equal(t, loginCookieName("tumblr"), cookies[0].Name)
+		assert.True(t, cookies[0].MaxAge < 0)
+	}
+}
+
+// loginCookieOrFail returns the provider's login cookie set by a login
+// response.
+func loginCookieOrFail(t *testing.T, w *httptest.ResponseRecorder, provider string) *http.Cookie {
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == loginCookieName(provider) {
+			assert.True(t, cookie.HttpOnly)
+			assert.True(t, cookie.Secure)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+			assert.NotEmpty(t, cookie.Value)
+			return cookie
+		}
+	}
+	t.Fatalf("login response missing %s cookie", loginCookieName(provider))
+	return nil
+}
+
+func TestRegistry_LoginCSRF(t *testing.T) {
+	twitter := newRegistryProvider(t, "twitter_token")
+	defer twitter.Close()
+
+	var errs []error
+	registry := NewRegistry(NewMemoryCredentialStore())
+	registry.Register("twitter", newRegistryConfig(twitter.URL, "https://example.com/twitter/callback"))
+	registry.OnSuccess = func(w http.ResponseWriter, req *http.Request, provider string, token *Token, extras url.Values) {
+		assert.Fail(t, "callback completed a login from another browser")
+	}
+	registry.OnError = func(w http.ResponseWriter, req *http.Request, provider string, err error) {
+		errs = append(errs, err)
+		w.WriteHeader(http.StatusForbidden)
+	}
+
+	// an attacker starts a login and authorizes with their own account
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest("GET", "/twitter/login", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+	loginCookieOrFail(t, w, "twitter")
+
+	// assert the victim's browser cannot complete the attacker's login
+	callbackURL := "/twitter/callback?oauth_token=twitter_token&oauth_verifier=verifier"
+	w = httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest("GET", callbackURL, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// assert a login cookie from another login does not match
+	victimProvider := newRegistryProvider(t, "victim_token")
+	defer victimProvider.Close()
+	registry.Register("twitter", newRegistryConfig(victimProvider.URL, "https://example.com/twitter/callback"))
+	w = httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest("GET", "/twitter/login", nil))
+	victimCookie := loginCookieOrFail(t, w, "twitter")
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", callbackURL, nil)
+	req.AddCookie(victimCookie)
+	registry.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	assert.Equal(t, []error{ErrLoginCookieMissing, ErrTemporaryCredentialNotFound}, errs)
+}
+
+func TestRegistry_ConcurrentLogins(t *testing.T) {
+	twitter := newRegistryProvider(t, "twitter_token")
+	defer twitter.Close()
+	tumblr := newRegistryProvider(t, "tumblr_token")
+	defer tumblr.Close()
+
+	var completed []string
+	registry := NewRegistry(NewMemoryCredentialStore())
+	registry.Register("twitter", newRegistryConfig(twitter.URL, "https://example.com/twitter/callback"))
+	registry.Register("tumblr", newRegistryConfig(tumblr.URL, "https://example.com/tumblr/callback"))
+	registry.OnSuccess = func(w http.ResponseWriter, req *http.Request, provider string, token *Token, extras url.Values) {
+		completed = append(completed, provider)
+	}
+
+	// a browser starts logins to both providers in separate tabs
+	var cookies []*http.Cookie
+	for _, provider := range []string{"twitter", "tumblr"} {
+		w := httptest.NewRecorder()
+		registry.ServeHTTP(w, httptest.NewRequest("GET", "/"+provider+"/login", nil))
+		assert.Equal(t, http.StatusFound, w.Code)
+		cookies = append(cookies, loginCookieOrFail(t, w, provider))
+	}
+
+	// assert an invalid callback does not end the login
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/twitter/callback?oauth_token=twitter_token", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	registry.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	// assert both logins complete
+	for _, provider := range []string{"twitter", "tumblr"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/"+provider+"/callback?oauth_token="+provider+"_token&oauth_verifier=verifier", nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		registry.ServeHTTP(w, req)
+	}
+	assert.Equal(t, []string{"twitter", "tumblr"}, completed)
+}
+
+func TestRegistry_NotFound(t *testing.T) {
+	registry := NewRegistry(NewMemoryCredentialStore())
+	registry.Register("twitter", &Config{})
+	paths := []string{"/", "/twitter", "/twitter/logout", "/twitter/login/extra", "/unknown/login"}
+	for _, path := range paths {
+		w := httptest.NewRecorder()
+		registry.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
+
+func TestRegistry_OnError(t *testing.T) {
+	var errs []error
+	registry := NewRegistry(NewMemoryCredentialStore())
+	registry.Register("twitter", &Config{})
+	registry.OnSuccess = func(w http.ResponseWriter, req *http.Request, provider string, token *Token, extras url.Values) {}
+	registry.OnError = func(w http.ResponseWriter, req *http.Request, provider string, err error) {
+		errs = append(errs, err)
+		w.WriteHeader(http.StatusTeapot)
+	}
+	cases := []string{"/unknown/login", "/twitter/callback?oauth_token=token&oauth_verifier=verifier"}
+	for _, path := range cases {
+		w := httptest.NewRecorder()
+		registry.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+	}
+	assert.Equal(t, []error{ErrUnknownProvider, ErrLoginCookieMissing}, errs)
+}
@@ -0,0 +1,172 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// Backoff returns the duration to wait before the given retry attempt,
+// starting from 1.
+type Backoff func(attempt int) time.Duration
+
+// ExponentialBackoff returns a Backoff which doubles the wait for each
+// attempt, starting from min and capped at max. Waits are jittered between
+// half and all of the computed duration.
+func ExponentialBackoff(min, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := max
+		if attempt < 32 {
+			if exp := min << uint(attempt-1); exp > 0 && exp < max {
+				d = exp
+			}
+		}
+		half := int64(d / 2)
+		return time.Duration(half + rand.Int63n(half+1))
+	}
+}
+
+// RetryTransport is an http.RoundTripper which retries idempotent requests
+// that fail with a transient error or a server error. It should wrap a
+// Transport, so every attempt is re-signed with a fresh nonce and timestamp.
+// Request bodies are replayed via the request's GetBody, requests with a
+// body which cannot be replayed are not retried.
+type RetryTransport struct {
+	// Base is the RoundTripper which signs and sends each attempt (e.g. a
+	// Transport). If nil, then http.DefaultTransport is used
+	Base http.RoundTripper
+	// MaxRetries is the maximum number of retries after the first attempt.
+	// Zero selects the default of 3 and a negative value disables retries
+	MaxRetries int
+	// Backoff returns the wait before each retry (defaults to an
+	// ExponentialBackoff from 100ms to 5s)
+	Backoff Backoff
+	// Idempotent reports whether a request may be safely retried (defaults
+	// to IdempotentRequest)
+	Idempotent func(req *http.Request) bool
+	// ShouldRetry reports whether an attempt's response or error is worth
+	// retrying (defaults to RetryableResponse)
+	ShouldRetry func(resp *http.Response, err error) bool
+}
+
+// RoundTrip sends the request via the Base RoundTripper, retrying failed
+// attempts with backoff until MaxRetries is reached or the request context
+// is done.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base().RoundTrip(req)
+	if !t.idempotent(req) || !canReplayBody(req) {
+		return resp, err
+	}
+	for attempt := 1; attempt <= t.maxRetries() && t.shouldRetry(resp, err); attempt++ {
+		if !sleepContext(req.Context(), t.backoff()(attempt)) {
+			return resp, err
+		}
+		req2 := cloneRequest(req)
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req2.Body = body
+		}
+		discardResponse(resp)
+		resp, err = t.base().RoundTrip(req2)
+	}
+	return resp, err
+}
+
+func (t *RetryTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *RetryTransport) maxRetries() int {
+	if t.MaxRetries < 0 {
+		return 0
+	}
+	if t.MaxRetries > 0 {
+		return t.MaxRetries
+	}
+	return defaultMaxRetries
+}
+
+func (t *RetryTransport) backoff() Backoff {
+	if t.Backoff != nil {
+		return t.Backoff
+	}
+	return ExponentialBackoff(defaultMinBackoff, defaultMaxBackoff)
+}
+
+func (t *RetryTransport) idempotent(req *http.Request) bool {
+	if t.Idempotent != nil {
+		return t.Idempotent(req)
+	}
+	return IdempotentRequest(req)
+}
+
+func (t *RetryTransport) shouldRetry(resp *http.Response, err error) bool {
+	if t.ShouldRetry != nil {
+		return t.ShouldRetry(resp, err)
+	}
+	return RetryableResponse(resp, err)
+}
+
+// IdempotentRequest reports whether the request uses an idempotent HTTP
+// method or carries an Idempotency-Key header.
+func IdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// RetryableResponse reports whether an attempt failed with a transport error
+// (other than a *TokenInvalidError) or a 5xx server error (other than 501
+// Not Implemented).
+func RetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		var tokenErr *TokenInvalidError
+		return !errors.As(err, &tokenErr)
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// canReplayBody reports whether the request body can be sent again.
+func canReplayBody(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// sleepContext waits for the duration and returns true, or returns false
+// early if the context is done.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// discardResponse drains and closes the body of a response which will not be
+// returned to the caller, so the connection may be reused.
+func discardResponse(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4<<10))
+		resp.Body.Close()
+	}
+}
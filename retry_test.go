@@ -0,0 +1,224 @@
+package oauth1
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// noBackoff retries immediately, for testing.
+func noBackoff(attempt int) time.Duration {
+	return 0
+}
+
+func newRetryTestClient(base http.RoundTripper) *http.Client {
+	tr := &Transport{
+		Base:   base,
+		source: StaticTokenSource(NewToken("token", "secret")),
+		auther: newAuther(NewConfig("consumer_key", "consumer_secret")),
+	}
+	return &http.Client{Transport: &RetryTransport{Base: tr, Backoff: noBackoff}}
+}
+
+func TestRetryTransport(t *testing.T) {
+	var nonces []string
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		nonces = append(nonces, params[oauthNonceParam])
+		if len(nonces) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	defer server.Close()
+
+	client := newRetryTestClient(nil)
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// assert each attempt was re-signed with a fresh nonce
+	if assert.Len(t, nonces, 3) {
+		assert.NotEqual(t, nonces[0], nonces[1])
+		assert.NotEqual(t, nonces[1], nonces[2])
+	}
+}
+
+func TestRetryTransport_replaysFormBody(t *testing.T) {
+	attempts := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		assert.Nil(t, req.ParseForm())
+		assert.Equal(t, "hello", req.PostForm.Get("status"))
+		if attempts < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	defer server.Close()
+
+	client := newRetryTestClient(nil)
+	values := url.Values{"status": {"hello"}}
+	req, err := http.NewRequest("POST", server.URL, strings.NewReader(values.Encode()))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, formContentType)
+	req.Header.Set("Idempotency-Key", "abc")
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryTransport_nonIdempotent(t *testing.T) {
+	attempts := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	client := newRetryTestClient(nil)
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryTransport_unreplayableBody(t *testing.T) {
+	attempts := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	client := newRetryTestClient(nil)
+	req, err := http.NewRequest("PUT", server.URL, ioutil.NopCloser(strings.NewReader("body")))
+	assert.Nil(t, err)
+	assert.Nil(t, req.GetBody)
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryTransport_maxRetries(t *testing.T) {
+	attempts := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	client := newRetryTestClient(nil)
+	client.Transport.(*RetryTransport).MaxRetries = 2
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryTransport_disabled(t *testing.T) {
+	attempts := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	// assert a negative MaxRetries disables retries
+	client := newRetryTestClient(nil)
+	client.Transport.(*RetryTransport).MaxRetries = -1
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryTransport_tokenInvalid(t *testing.T) {
+	attempts := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.Header().Set(contentType, "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors": [{"code": 89, "message": "Invalid or expired token."}]}`))
+	})
+	defer server.Close()
+
+	config := NewConfig("consumer_key", "consumer_secret")
+	config.OnTokenInvalid = func(err *TokenInvalidError) {}
+	client := newRetryTestClient(nil)
+	client.Transport.(*RetryTransport).Base.(*Transport).auther = newAuther(config)
+	_, err := client.Get(server.URL)
+	assert.Error(t, err)
+	// assert invalid tokens are not retried
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryTransport_contextCanceled(t *testing.T) {
+	attempts := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	client := newRetryTestClient(nil)
+	client.Transport.(*RetryTransport).Backoff = func(int) time.Duration { return time.Hour }
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	assert.Nil(t, err)
+	resp, err := client.Do(req)
+	// assert the last response is returned without waiting for the backoff
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second)
+	cases := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{100, time.Second},
+	}
+	for _, c := range cases {
+		d := backoff(c.attempt)
+		assert.True(t, d >= c.max/2 && d <= c.max, "attempt %d backoff %v", c.attempt, d)
+	}
+}
+
+func TestIdempotentRequest(t *testing.T) {
+	cases := []struct {
+		method     string
+		header     string
+		idempotent bool
+	}{
+		{"GET", "", true},
+		{"PUT", "", true},
+		{"DELETE", "", true},
+		{"POST", "", false},
+		{"PATCH", "", false},
+		{"POST", "Idempotency-Key", true},
+		{"POST", "X-Idempotency-Key", true},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, "https://example.com", nil)
+		assert.Nil(t, err)
+		if c.header != "" {
+			req.Header.Set(c.header, "key")
+		}
+		assert.Equal(t, c.idempotent, IdempotentRequest(req))
+	}
+}
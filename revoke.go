@@ -0,0 +1,90 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrRevokeNotSupported is returned when revoking a token for an Endpoint
+// without a RevokeURL.
+var ErrRevokeNotSupported = errors.New("oauth1: Endpoint has no RevokeURL")
+
+// RevokeResult is the outcome of a successful token revocation.
+type RevokeResult int
+
+const (
+	// Revoked means the provider revoked the access token.
+	Revoked RevokeResult = iota + 1
+	// AlreadyRevoked means the provider rejected the access token because
+	// it was already revoked or expired, so it can no longer be used.
+	AlreadyRevoked
+)
+
+func (r RevokeResult) String() string {
+	switch r {
+	case Revoked:
+		return "revoked"
+	case AlreadyRevoked:
+		return "already revoked"
+	}
+	return fmt.Sprintf("RevokeResult(%d)", int(r))
+}
+
+// RevokeError is returned when a provider fails to revoke an access token.
+type RevokeError struct {
+	// StatusCode of the provider response
+	StatusCode int
+	// Problem is the oauth_problem reported by the provider, if any
+	Problem string
+	// Body of the provider response
+	Body []byte
+}
+
+func (e *RevokeError) Error() string {
+	if e.Problem != "" {
+		return fmt.Sprintf("oauth1: revoke failed with status %d: %s", e.StatusCode, e.Problem)
+	}
+	return fmt.Sprintf("oauth1: revoke failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Revoke revokes an access Token (e.g. on logout or account unlink) by
+// POSTing a request signed with the Token to the Endpoint RevokeURL (e.g.
+// Twitter's oauth/invalidate_token). A Token which the provider rejects as
+// already revoked or expired results in AlreadyRevoked. Other failures
+// return a *RevokeError.
+func (c *Config) Revoke(ctx context.Context, token *Token) (RevokeResult, error) {
+	if c.Endpoint.RevokeURL == "" {
+		return 0, ErrRevokeNotSupported
+	}
+	if token == nil {
+		return 0, errors.New("oauth1: Token is nil")
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.Endpoint.RevokeURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	err = newAuther(c).setRequestAuthHeader(req, token)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("oauth1: error reading Body: %v", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return Revoked, nil
+	}
+	problem := parseOAuthProblem(resp.Header, body)
+	if isInvalidTokenResponse(resp.StatusCode, problem, body) {
+		return AlreadyRevoked, nil
+	}
+	return 0, &RevokeError{StatusCode: resp.StatusCode, Problem: problem, Body: body}
+}
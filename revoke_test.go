@@ -0,0 +1,78 @@
+package oauth1
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigRevoke(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "POST", req.Method)
+		params, err := config.VerifyRequest(req, "token_secret")
+		assert.Nil(t, err)
+		assert.Equal(t, "token", params[oauthTokenParam])
+		w.Write([]byte(`{"access_token": "token"}`))
+	})
+	defer server.Close()
+
+	config.Endpoint.RevokeURL = server.URL + "/oauth/invalidate_token"
+	result, err := config.Revoke(context.Background(), NewToken("token", "token_secret"))
+	assert.Nil(t, err)
+	assert.Equal(t, Revoked, result)
+	assert.Equal(t, "revoked", result.String())
+}
+
+func TestConfigRevoke_AlreadyRevoked(t *testing.T) {
+	cases := []func(w http.ResponseWriter){
+		func(w http.ResponseWriter) {
+			w.Header().Set(wwwAuthenticateHeader, `OAuth realm="https://api.example.com/", oauth_problem="token_revoked"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		},
+		func(w http.ResponseWriter) {
+			w.Header().Set(contentType, formContentType)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("oauth_problem=token_expired"))
+		},
+		func(w http.ResponseWriter) {
+			w.Header().Set(contentType, "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors": [{"code": 89, "message": "Invalid or expired token."}]}`))
+		},
+	}
+	for _, respond := range cases {
+		server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+			respond(w)
+		})
+		config := &Config{Endpoint: Endpoint{RevokeURL: server.URL}}
+		result, err := config.Revoke(context.Background(), NewToken("token", "token_secret"))
+		assert.Nil(t, err)
+		assert.Equal(t, AlreadyRevoked, result)
+		server.Close()
+	}
+}
+
+func TestConfigRevoke_Failure(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(contentType, formContentType)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("oauth_problem=signature_invalid"))
+	})
+	defer server.Close()
+
+	config := &Config{Endpoint: Endpoint{RevokeURL: server.URL}}
+	result, err := config.Revoke(context.Background(), NewToken("token", "token_secret"))
+	assert.Equal(t, RevokeResult(0), result)
+	assert.Equal(t, &RevokeError{StatusCode: 401, Problem: "signature_invalid", Body: []byte("oauth_problem=signature_invalid")}, err)
+	assert.Equal(t, "oauth1: revoke failed with status 401: signature_invalid", err.Error())
+}
+
+func TestConfigRevoke_NotSupported(t *testing.T) {
+	config := &Config{}
+	result, err := config.Revoke(context.Background(), NewToken("token", "token_secret"))
+	assert.Equal(t, RevokeResult(0), result)
+	assert.Equal(t, ErrRevokeNotSupported, err)
+}
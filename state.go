@@ -0,0 +1,130 @@
+package oauth1
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultStateParam = "state"
+
+// ErrInvalidState is returned when a callback's state value is missing, was
+// not signed with the CallbackState Key, or has expired.
+var ErrInvalidState = errors.New("oauth1: invalid callback state")
+
+// CallbackState embeds application state (e.g. a return-to path or tenant
+// ID) in per-login callback URLs. State values are signed with HMAC-SHA256
+// so they cannot be tampered with while the user authorizes with the
+// provider. State is not encrypted, so it should not contain secrets.
+type CallbackState struct {
+	// Key signs state values and should be at least 32 random bytes
+	Key []byte
+	// Param is the callback URL query parameter which carries the state
+	// (defaults to "state")
+	Param string
+	// MaxAge is how long a state value is valid (defaults to no expiry)
+	MaxAge time.Duration
+
+	clock clock
+}
+
+// CallbackURL returns the callback URL with the signed state added to its
+// query. Pass the URL as the RequestTokenOptions CallbackURL (and, for
+// OAuth10 providers, the AuthorizationURLOptions CallbackURL).
+func (s *CallbackState) CallbackURL(callbackURL, state string) (string, error) {
+	if len(s.Key) == 0 {
+		return "", errors.New("oauth1: CallbackState Key is empty")
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+	values := u.Query()
+	values.Set(s.param(), s.sign(state))
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
+
+// Verify verifies a signed state value from a callback URL and returns the
+// application state.
+func (s *CallbackState) Verify(value string) (string, error) {
+	if len(s.Key) == 0 {
+		return "", errors.New("oauth1: CallbackState Key is empty")
+	}
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidState
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(mac, s.mac(parts[0]+"."+parts[1])) {
+		return "", ErrInvalidState
+	}
+	issued, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrInvalidState
+	}
+	if s.MaxAge > 0 && s.now().After(time.Unix(issued, 0).Add(s.MaxAge)) {
+		return "", ErrInvalidState
+	}
+	state, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidState
+	}
+	return string(state), nil
+}
+
+// ParseAuthorizationCallbackWithState parses an OAuth1 authorization callback
+// request like ParseAuthorizationCallback and also returns the application
+// state verified by the CallbackState. Use the Config method for OAuth10
+// providers, which may omit oauth_verifier.
+func ParseAuthorizationCallbackWithState(req *http.Request, s *CallbackState) (requestToken, verifier, state string, err error) {
+	return (&Config{}).ParseAuthorizationCallbackWithState(req, s)
+}
+
+// ParseAuthorizationCallbackWithState parses an OAuth1 authorization callback
+// request like the Config ParseAuthorizationCallback method and also returns
+// the application state verified by the CallbackState.
+func (c *Config) ParseAuthorizationCallbackWithState(req *http.Request, s *CallbackState) (requestToken, verifier, state string, err error) {
+	requestToken, verifier, err = c.ParseAuthorizationCallback(req)
+	if err != nil {
+		return "", "", "", err
+	}
+	state, err = s.Verify(req.Form.Get(s.param()))
+	if err != nil {
+		return "", "", "", err
+	}
+	return requestToken, verifier, state, nil
+}
+
+// sign returns the state value, which is the base64 encoded state, the
+// issue time, and the HMAC of both, joined by ".".
+func (s *CallbackState) sign(state string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(state)) + "." + strconv.FormatInt(s.now().Unix(), 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+func (s *CallbackState) mac(payload string) []byte {
+	h := hmac.New(sha256.New, s.Key)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+func (s *CallbackState) param() string {
+	if s.Param != "" {
+		return s.Param
+	}
+	return defaultStateParam
+}
+
+func (s *CallbackState) now() time.Time {
+	if s.clock != nil {
+		return s.clock.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,109 @@
+package oauth1
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallbackState(t *testing.T) {
+	s := &CallbackState{Key: []byte("a-secret-key-of-at-least-32-bytes")}
+	callbackURL, err := s.CallbackURL("https://example.com/callback?tenant=acme", "/return/to?page=2")
+	assert.Nil(t, err)
+	u, err := url.Parse(callbackURL)
+	assert.Nil(t, err)
+	assert.Equal(t, "acme", u.Query().Get("tenant"))
+	state, err := s.Verify(u.Query().Get("state"))
+	assert.Nil(t, err)
+	assert.Equal(t, "/return/to?page=2", state)
+
+	// assert state signed with another key is rejected
+	other := &CallbackState{Key: []byte("another-secret-key-of-32-bytes!!")}
+	state, err = other.Verify(u.Query().Get("state"))
+	assert.Equal(t, "", state)
+	assert.Equal(t, ErrInvalidState, err)
+}
+
+func TestCallbackState_Invalid(t *testing.T) {
+	s := &CallbackState{Key: []byte("a-secret-key-of-at-least-32-bytes")}
+	valid := s.sign("tenant")
+	tampered := "dGVuYW50Mg" + valid[len("dGVuYW50"):]
+	cases := []string{"", "tenant", "a.b", "a.b.c", tampered}
+	for _, value := range cases {
+		state, err := s.Verify(value)
+		assert.Equal(t, "", state)
+		assert.Equal(t, ErrInvalidState, err, value)
+	}
+}
+
+func TestCallbackState_MaxAge(t *testing.T) {
+	clock := &fixedClock{time.Unix(50037133, 0)}
+	s := &CallbackState{
+		Key:    []byte("a-secret-key-of-at-least-32-bytes"),
+		MaxAge: 10 * time.Minute,
+		clock:  clock,
+	}
+	value := s.sign("tenant")
+	clock.now = clock.now.Add(9 * time.Minute)
+	state, err := s.Verify(value)
+	assert.Nil(t, err)
+	assert.Equal(t, "tenant", state)
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	state, err = s.Verify(value)
+	assert.Equal(t, "", state)
+	assert.Equal(t, ErrInvalidState, err)
+}
+
+func TestCallbackState_EmptyKey(t *testing.T) {
+	s := &CallbackState{}
+	_, err := s.CallbackURL("https://example.com/callback", "state")
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: CallbackState Key is empty", err.Error())
+	}
+}
+
+func TestParseAuthorizationCallbackWithState(t *testing.T) {
+	s := &CallbackState{
+		Key:   []byte("a-secret-key-of-at-least-32-bytes"),
+		Param: "app_state",
+	}
+	callbackURL, err := s.CallbackURL("https://example.com/callback", "tenant-1")
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest("GET", callbackURL+"&oauth_token=token&oauth_verifier=verifier", nil)
+	requestToken, verifier, state, err := ParseAuthorizationCallbackWithState(req, s)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", requestToken)
+	assert.Equal(t, "verifier", verifier)
+	assert.Equal(t, "tenant-1", state)
+
+	req = httptest.NewRequest("GET", "https://example.com/callback?oauth_token=token&oauth_verifier=verifier", nil)
+	requestToken, verifier, state, err = ParseAuthorizationCallbackWithState(req, s)
+	assert.Equal(t, ErrInvalidState, err)
+	assert.Equal(t, "", requestToken+verifier+state)
+}
+
+func TestConfigParseAuthorizationCallbackWithState_OAuth10(t *testing.T) {
+	s := &CallbackState{Key: []byte("a-secret-key-of-at-least-32-bytes")}
+	callbackURL, err := s.CallbackURL("https://example.com/callback", "tenant-1")
+	assert.Nil(t, err)
+
+	// assert OAuth10 callbacks without an oauth_verifier are accepted
+	config := &Config{ProtocolVersion: OAuth10}
+	req := httptest.NewRequest("GET", callbackURL+"&oauth_token=token", nil)
+	requestToken, verifier, state, err := config.ParseAuthorizationCallbackWithState(req, s)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", requestToken)
+	assert.Equal(t, "", verifier)
+	assert.Equal(t, "tenant-1", state)
+
+	req = httptest.NewRequest("GET", callbackURL+"&oauth_token=token", nil)
+	_, _, _, err = ParseAuthorizationCallbackWithState(req, s)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Request missing oauth_token or oauth_verifier", err.Error())
+	}
+}
@@ -0,0 +1,197 @@
+package oauth1
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultStreamMinBackoff     = time.Second
+	defaultStreamMaxBackoff     = 5 * time.Minute
+	defaultStreamMaxMessageSize = 1 << 20 // 1 MB
+)
+
+// Stream reads messages from a long-lived streaming endpoint (e.g. filtered
+// streams or change feeds). Dropped connections are reconnected with
+// jittered exponential backoff and, since the Client's Transport signs every
+// request, each connection attempt is re-signed with a fresh nonce and
+// timestamp.
+type Stream struct {
+	// Client signs and sends each connection request (e.g. Config.Client)
+	Client *http.Client
+	// Backoff returns the wait before each reconnect (defaults to an
+	// ExponentialBackoff from 1s to 5m). Attempts are counted from the last
+	// successful connection
+	Backoff Backoff
+	// Split splits a response body into messages (defaults to
+	// bufio.ScanLines for line-delimited streams). Empty messages, such as
+	// keep-alive newlines, are skipped
+	Split bufio.SplitFunc
+	// MaxMessageSize is the maximum size of a message in bytes (defaults
+	// to 1MB)
+	MaxMessageSize int
+}
+
+// Run connects to the streaming endpoint of the given request and calls fn
+// with each message, reconnecting when the connection drops or the server
+// responds with a 420, 429, or 5xx status. Run returns the context's error
+// when ctx is done, the error returned by fn, or an error for other non-200
+// statuses. The request body, if any, is replayed via GetBody.
+func (s *Stream) Run(ctx context.Context, req *http.Request, fn func(message []byte) error) error {
+	attempt := 0
+	for {
+		connected, err := s.connect(ctx, req, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if handlerErr, ok := err.(*streamHandlerError); ok {
+			return handlerErr.err
+		}
+		if !isRetryableStreamError(err) {
+			return err
+		}
+		if connected {
+			attempt = 0
+		}
+		attempt++
+		if !sleepContext(ctx, s.backoff()(attempt)) {
+			return ctx.Err()
+		}
+	}
+}
+
+// Messages connects to the streaming endpoint of the given request and
+// returns a channel of messages. The error channel receives the error which
+// ended the stream, after which both channels are closed. See Run.
+func (s *Stream) Messages(ctx context.Context, req *http.Request) (<-chan []byte, <-chan error) {
+	messages := make(chan []byte)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(messages)
+		defer close(errs)
+		errs <- s.Run(ctx, req, func(message []byte) error {
+			select {
+			case messages <- message:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return messages, errs
+}
+
+// streamStatusError is returned for non-200 connection responses.
+type streamStatusError struct {
+	statusCode int
+	body       []byte
+}
+
+func (e *streamStatusError) Error() string {
+	return fmt.Sprintf("oauth1: invalid status %d: %s", e.statusCode, e.body)
+}
+
+// streamHandlerError wraps errors returned by the message handler so they
+// are not mistaken for connection errors.
+type streamHandlerError struct {
+	err error
+}
+
+func (e *streamHandlerError) Error() string {
+	return e.err.Error()
+}
+
+// isRetryableStreamError reports whether a connection error or status
+// should be retried after backoff. An invalid access token is not retried.
+func isRetryableStreamError(err error) bool {
+	if err == bufio.ErrTooLong {
+		return false
+	}
+	var tokenErr *TokenInvalidError
+	if errors.As(err, &tokenErr) {
+		return false
+	}
+	statusErr, ok := err.(*streamStatusError)
+	if !ok {
+		// connection and read errors
+		return true
+	}
+	code := statusErr.statusCode
+	return code == 420 || code == http.StatusTooManyRequests || code >= 500
+}
+
+// connect sends one signed connection request and reads messages until the
+// connection ends. Returns whether the connection was established.
+func (s *Stream) connect(ctx context.Context, req *http.Request, fn func(message []byte) error) (bool, error) {
+	req2 := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return false, err
+		}
+		req2.Body = body
+	}
+	resp, err := s.Client.Do(req2)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return false, &streamStatusError{statusCode: resp.StatusCode, body: body}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	maxSize := s.maxMessageSize()
+	scanner.Buffer(make([]byte, 0, minInt(4096, maxSize)), maxSize)
+	scanner.Split(s.split())
+	for scanner.Scan() {
+		message := bytes.TrimSpace(scanner.Bytes())
+		if len(message) == 0 {
+			continue
+		}
+		// scanner reuses its buffer, copy messages handed to fn
+		if err := fn(append([]byte(nil), message...)); err != nil {
+			return true, &streamHandlerError{err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, io.EOF
+}
+
+func (s *Stream) backoff() Backoff {
+	if s.Backoff != nil {
+		return s.Backoff
+	}
+	return ExponentialBackoff(defaultStreamMinBackoff, defaultStreamMaxBackoff)
+}
+
+func (s *Stream) split() bufio.SplitFunc {
+	if s.Split != nil {
+		return s.Split
+	}
+	return bufio.ScanLines
+}
+
+func (s *Stream) maxMessageSize() int {
+	if s.MaxMessageSize > 0 {
+		return s.MaxMessageSize
+	}
+	return defaultStreamMaxMessageSize
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,146 @@
+package oauth1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	var mu sync.Mutex
+	var nonces []string
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		mu.Lock()
+		nonces = append(nonces, params[oauthNonceParam])
+		connection := len(nonces)
+		mu.Unlock()
+		if connection == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// keep-alive newlines are skipped, then the connection drops
+		fmt.Fprintf(w, "message %d-a\r\n\r\nmessage %d-b\r\n", connection, connection)
+	})
+	defer server.Close()
+
+	stream := &Stream{
+		Client:  NewConfig("consumer_key", "consumer_secret").Client(NoContext, NewToken("token", "secret")),
+		Backoff: noBackoff,
+	}
+	req, err := http.NewRequest("GET", server.URL+"/stream", nil)
+	assert.Nil(t, err)
+	var messages []string
+	done := errors.New("done")
+	err = stream.Run(context.Background(), req, func(message []byte) error {
+		messages = append(messages, string(message))
+		if len(messages) == 4 {
+			return done
+		}
+		return nil
+	})
+	// assert the handler error ends the stream
+	assert.Equal(t, done, err)
+	assert.Equal(t, []string{"message 1-a", "message 1-b", "message 3-a", "message 3-b"}, messages)
+	// assert each connection attempt was re-signed
+	if assert.Len(t, nonces, 3) {
+		assert.NotEqual(t, nonces[0], nonces[1])
+		assert.NotEqual(t, nonces[1], nonces[2])
+	}
+}
+
+func TestStream_invalidStatus(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("unauthorized"))
+	})
+	defer server.Close()
+
+	stream := &Stream{Client: http.DefaultClient, Backoff: noBackoff}
+	req, err := http.NewRequest("GET", server.URL, nil)
+	assert.Nil(t, err)
+	err = stream.Run(context.Background(), req, func(message []byte) error {
+		return nil
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: invalid status 401: unauthorized", err.Error())
+	}
+}
+
+func TestStream_tokenInvalid(t *testing.T) {
+	connections := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		connections++
+		w.Header().Set(wwwAuthenticateHeader, `OAuth oauth_problem="token_revoked"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer server.Close()
+
+	calls := 0
+	config := NewConfig("consumer_key", "consumer_secret")
+	config.OnTokenInvalid = func(err *TokenInvalidError) {
+		calls++
+	}
+	stream := &Stream{
+		Client:  config.Client(NoContext, NewToken("token", "secret")),
+		Backoff: noBackoff,
+	}
+	req, err := http.NewRequest("GET", server.URL+"/stream", nil)
+	assert.Nil(t, err)
+	err = stream.Run(context.Background(), req, func(message []byte) error {
+		return nil
+	})
+	// assert an invalid token ends the stream instead of reconnecting
+	var tokenErr *TokenInvalidError
+	if assert.True(t, errors.As(err, &tokenErr)) {
+		assert.Equal(t, "token_revoked", tokenErr.Problem)
+	}
+	assert.Equal(t, 1, connections)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStream_contextCanceled(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("hello\n"))
+		w.(http.Flusher).Flush()
+		<-req.Context().Done()
+	})
+	defer server.Close()
+
+	stream := &Stream{Client: http.DefaultClient}
+	req, err := http.NewRequest("GET", server.URL, nil)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	messages, errs := stream.Messages(ctx, req)
+	assert.Equal(t, "hello", string(<-messages))
+	cancel()
+	select {
+	case err := <-errs:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "stream did not stop on context cancellation")
+	}
+	_, ok := <-messages
+	assert.False(t, ok)
+}
+
+func TestStream_maxMessageSize(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("a message which is too long\n"))
+	})
+	defer server.Close()
+
+	stream := &Stream{Client: http.DefaultClient, Backoff: noBackoff, MaxMessageSize: 8}
+	req, err := http.NewRequest("GET", server.URL, nil)
+	assert.Nil(t, err)
+	err = stream.Run(context.Background(), req, func(message []byte) error {
+		return nil
+	})
+	assert.Error(t, err)
+}
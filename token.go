@@ -2,6 +2,18 @@ package oauth1
 
 import (
 	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	oauthExpiresInParam              = "oauth_expires_in"
+	oauthAuthorizationExpiresInParam = "oauth_authorization_expires_in"
+	oauthSessionHandleParam          = "oauth_session_handle"
+	// renew expiring tokens a little before they expire
+	tokenExpiryDelta = time.Minute
 )
 
 // A TokenSource can return a Token.
@@ -11,9 +23,31 @@ type TokenSource interface {
 
 // Token is an AccessToken (token credential) which allows a consumer (client)
 // to access resources from an OAuth1 provider server.
+//
+// Tokens are comparable, but a Token obtained from a token response (e.g.
+// by Exchange) carries its response parameters (see Extra), so it is only
+// equal to copies of itself, not to a Token with the same fields. Compare
+// the Token and TokenSecret fields to compare credentials.
 type Token struct {
 	Token       string
 	TokenSecret string
+	// Expiry is when the token expires, for providers which use the OAuth
+	// Session Extension (zero if the token does not expire)
+	Expiry time.Time
+	// SessionHandle (oauth_session_handle) is used to renew an expiring token
+	SessionHandle string
+	// AuthorizationExpiry is when the SessionHandle can no longer be used to
+	// renew the token (zero if unknown)
+	AuthorizationExpiry time.Time
+
+	// extra holds the token response parameters, if any. It is a pointer so
+	// Tokens remain comparable
+	extra *tokenExtra
+}
+
+// tokenExtra is the token response a Token was obtained from.
+type tokenExtra struct {
+	values url.Values
 }
 
 // NewToken returns a new Token with the given token and token secret.
@@ -24,8 +58,64 @@ func NewToken(token, tokenSecret string) *Token {
 	}
 }
 
+// Expired reports whether the Token has an expiry which has passed or is
+// about to pass.
+func (t *Token) Expired() bool {
+	return t.expiredAt(time.Now())
+}
+
+func (t *Token) expiredAt(now time.Time) bool {
+	if t.Expiry.IsZero() {
+		return false
+	}
+	return t.Expiry.Add(-tokenExpiryDelta).Before(now)
+}
+
+// Extra returns a parameter of the token response, such as a provider user
+// id or screen name. Returns "" for Tokens not obtained from a response.
+func (t *Token) Extra(key string) string {
+	if t.extra == nil {
+		return ""
+	}
+	return t.extra.values.Get(key)
+}
+
+// extraValues returns a copy of the token response parameters.
+func (t *Token) extraValues() url.Values {
+	values := url.Values{}
+	if t.extra == nil {
+		return values
+	}
+	for key, value := range t.extra.values {
+		values[key] = append([]string(nil), value...)
+	}
+	return values
+}
+
+// tokenFromValues returns a Token from token response parameters, including
+// OAuth Session Extension expiries relative to the given time.
+func tokenFromValues(values url.Values, now time.Time) (*Token, error) {
+	token := &Token{
+		Token:         values.Get(oauthTokenParam),
+		TokenSecret:   values.Get(oauthTokenSecretParam),
+		SessionHandle: values.Get(oauthSessionHandleParam),
+		extra:         &tokenExtra{values: values},
+	}
+	if token.Token == "" || token.TokenSecret == "" {
+		return nil, errors.New("oauth1: Response missing oauth_token or oauth_token_secret")
+	}
+	if seconds, err := strconv.ParseInt(values.Get(oauthExpiresInParam), 10, 64); err == nil {
+		token.Expiry = now.Add(time.Duration(seconds) * time.Second)
+	}
+	if seconds, err := strconv.ParseInt(values.Get(oauthAuthorizationExpiresInParam), 10, 64); err == nil {
+		token.AuthorizationExpiry = now.Add(time.Duration(seconds) * time.Second)
+	}
+	return token, nil
+}
+
 // StaticTokenSource returns a TokenSource which always returns the same Token.
-// This is appropriate for tokens which do not have a time expiration.
+// This is appropriate for tokens which do not have a time expiration. Use
+// Config.TokenSource for tokens which expire.
 func StaticTokenSource(token *Token) TokenSource {
 	return staticTokenSource{token}
 }
@@ -41,3 +131,42 @@ func (s staticTokenSource) Token() (*Token, error) {
 	}
 	return s.token, nil
 }
+
+// refreshingTokenSource is a TokenSource which renews its Token via the
+// OAuth Session Extension when it nears expiry. It is safe for concurrent
+// use and only one renewal is in flight at a time.
+type refreshingTokenSource struct {
+	config *Config
+	mu     sync.Mutex
+	token  *Token
+	clock  clock
+}
+
+func (s *refreshingTokenSource) Token() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return nil, errors.New("oauth1: Token is nil")
+	}
+	now := s.now()
+	if !s.token.expiredAt(now) {
+		return s.token, nil
+	}
+	token, err := s.config.RefreshToken(s.token)
+	if err != nil {
+		// renewal starts early, so keep using a Token which has not expired
+		if now.Before(s.token.Expiry) {
+			return s.token, nil
+		}
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
+
+func (s *refreshingTokenSource) now() time.Time {
+	if s.clock != nil {
+		return s.clock.Now()
+	}
+	return time.Now()
+}
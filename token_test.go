@@ -1,7 +1,11 @@
 package oauth1
 
 import (
+	"net/http"
+	"net/url"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -29,3 +33,173 @@ func TestStaticTokenSourceEmpty(t *testing.T) {
 		assert.Equal(t, "oauth1: Token is nil", err.Error())
 	}
 }
+
+func TestTokenFromValues(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	values := url.Values{}
+	values.Add("oauth_token", "token")
+	values.Add("oauth_token_secret", "secret")
+	values.Add("oauth_expires_in", "3600")
+	values.Add("oauth_authorization_expires_in", "86400")
+	values.Add("oauth_session_handle", "handle")
+	values.Add("xoauth_yahoo_guid", "guid")
+	tk, err := tokenFromValues(values, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", tk.Token)
+	assert.Equal(t, "secret", tk.TokenSecret)
+	assert.Equal(t, "handle", tk.SessionHandle)
+	assert.Equal(t, now.Add(time.Hour), tk.Expiry)
+	assert.Equal(t, now.Add(24*time.Hour), tk.AuthorizationExpiry)
+	assert.Equal(t, "guid", tk.Extra("xoauth_yahoo_guid"))
+}
+
+func TestTokenFromValues_NoExpiry(t *testing.T) {
+	values := url.Values{}
+	values.Add("oauth_token", "token")
+	values.Add("oauth_token_secret", "secret")
+	tk, err := tokenFromValues(values, time.Now())
+	assert.Nil(t, err)
+	assert.True(t, tk.Expiry.IsZero())
+	assert.False(t, tk.Expired())
+}
+
+func TestTokenFromValues_MissingTokenOrSecret(t *testing.T) {
+	values := url.Values{}
+	values.Add("oauth_token", "token")
+	tk, err := tokenFromValues(values, time.Now())
+	assert.Nil(t, tk)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Response missing oauth_token or oauth_token_secret", err.Error())
+	}
+}
+
+func TestToken_Expired(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	cases := []struct {
+		expiry  time.Time
+		expired bool
+	}{
+		{time.Time{}, false},
+		{now.Add(time.Hour), false},
+		{now.Add(30 * time.Second), true},
+		{now.Add(-time.Hour), true},
+	}
+	for _, c := range cases {
+		tk := &Token{Expiry: c.expiry}
+		assert.Equal(t, c.expired, tk.expiredAt(now))
+	}
+}
+
+func TestToken_ExtraEmpty(t *testing.T) {
+	assert.Equal(t, "", NewToken("t", "s").Extra("screen_name"))
+}
+
+func TestToken_Comparable(t *testing.T) {
+	// assert Tokens can be compared and used as map keys
+	tokens := map[Token]bool{*NewToken("t", "s"): true}
+	assert.True(t, tokens[*NewToken("t", "s")])
+	assert.True(t, *NewToken("t", "s") == *NewToken("t", "s"))
+
+	// assert Tokens from token responses only equal copies of themselves
+	tk, err := tokenFromValues(url.Values{"oauth_token": {"t"}, "oauth_token_secret": {"s"}}, time.Now())
+	assert.Nil(t, err)
+	copied := *tk
+	assert.True(t, copied == *tk)
+	assert.False(t, *tk == *NewToken("t", "s"))
+	assert.Equal(t, NewToken("t", "s").Token, tk.Token)
+	assert.Equal(t, NewToken("t", "s").TokenSecret, tk.TokenSecret)
+}
+
+func TestRefreshingTokenSource(t *testing.T) {
+	// tokens which are not expiring are returned without renewal
+	tk := &Token{Token: "t", TokenSecret: "s", Expiry: time.Now().Add(time.Hour), SessionHandle: "handle"}
+	ts := NewConfig("key", "secret").TokenSource(tk)
+	got, err := ts.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, tk, got)
+}
+
+func TestRefreshingTokenSource_Refresh(t *testing.T) {
+	var mu sync.Mutex
+	refreshes := 0
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "expiring_token", params[oauthTokenParam])
+		assert.Equal(t, "handle", params[oauthSessionHandleParam])
+		mu.Lock()
+		refreshes++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set(contentType, formContentType)
+		w.Write([]byte("oauth_token=renewed_token&oauth_token_secret=renewed_secret&oauth_expires_in=3600"))
+	})
+	defer server.Close()
+
+	config := &Config{
+		ConsumerKey:    "key",
+		ConsumerSecret: "secret",
+		Endpoint:       Endpoint{AccessTokenURL: server.URL},
+	}
+	expiring := &Token{
+		Token:         "expiring_token",
+		TokenSecret:   "expiring_secret",
+		Expiry:        time.Now().Add(10 * time.Second),
+		SessionHandle: "handle",
+	}
+	ts := config.TokenSource(expiring)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tk, err := ts.Token()
+			assert.Nil(t, err)
+			if assert.NotNil(t, tk) {
+				assert.Equal(t, "renewed_token", tk.Token)
+				assert.Equal(t, "renewed_secret", tk.TokenSecret)
+				// the session handle is kept when not renewed
+				assert.Equal(t, "handle", tk.SessionHandle)
+			}
+		}()
+	}
+	wg.Wait()
+	// assert concurrent callers share a single renewal
+	assert.Equal(t, 1, refreshes)
+}
+
+func TestRefreshingTokenSource_RefreshError(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer server.Close()
+
+	config := &Config{Endpoint: Endpoint{AccessTokenURL: server.URL}}
+	now := time.Now()
+	expiring := &Token{
+		Token:         "expiring_token",
+		TokenSecret:   "expiring_secret",
+		Expiry:        now.Add(30 * time.Second),
+		SessionHandle: "handle",
+	}
+	clock := &fixedClock{now}
+	ts := &refreshingTokenSource{config: config, token: expiring, clock: clock}
+	// assert a failed renewal returns the Token until it expires
+	tk, err := ts.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, expiring, tk)
+
+	clock.now = now.Add(time.Minute)
+	tk, err = ts.Token()
+	assert.Nil(t, tk)
+	assert.Error(t, err)
+}
+
+func TestRefreshingTokenSource_Empty(t *testing.T) {
+	ts := NewConfig("key", "secret").TokenSource(nil)
+	tk, err := ts.Token()
+	assert.Nil(t, tk)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Token is nil", err.Error())
+	}
+}
@@ -0,0 +1,147 @@
+package oauth1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SwappableTokenSource is a TokenSource whose Token can be replaced while
+// clients use it (e.g. when a user re-authorizes or credentials rotate). It
+// is safe for concurrent use. Each request is signed with the Token and
+// secret pair current when the request started.
+type SwappableTokenSource struct {
+	token atomic.Pointer[Token]
+}
+
+// NewSwappableTokenSource returns a SwappableTokenSource which returns the
+// given Token until it is replaced.
+func NewSwappableTokenSource(token *Token) *SwappableTokenSource {
+	s := &SwappableTokenSource{}
+	s.Set(token)
+	return s
+}
+
+// Set atomically replaces the Token. A copy of the Token is stored, so
+// later changes to the given Token do not affect signing.
+func (s *SwappableTokenSource) Set(token *Token) {
+	if token == nil {
+		s.token.Store(nil)
+		return
+	}
+	copied := *token
+	s.token.Store(&copied)
+}
+
+// Token returns the current Token.
+func (s *SwappableTokenSource) Token() (*Token, error) {
+	token := s.token.Load()
+	if token == nil {
+		return nil, errors.New("oauth1: Token is nil")
+	}
+	return token, nil
+}
+
+// A TokenLoader loads the current Token (e.g. from a file or secret store).
+type TokenLoader func() (*Token, error)
+
+// ReloadingTokenSource returns a TokenSource which loads its Token with the
+// TokenLoader and reloads it when it is older than the interval. Reloads
+// happen lazily, when a request needs a Token, so an interval of zero
+// reloads for every request. If a reload fails, the previous Token is
+// returned and the reload is retried for the next request. It is safe for
+// concurrent use.
+func ReloadingTokenSource(loader TokenLoader, interval time.Duration) TokenSource {
+	return &reloadingTokenSource{loader: loader, interval: interval}
+}
+
+// reloadingTokenSource is a TokenSource which reloads its Token with a
+// TokenLoader at an interval.
+type reloadingTokenSource struct {
+	loader   TokenLoader
+	interval time.Duration
+	mu       sync.Mutex
+	token    *Token
+	loaded   time.Time
+	clock    clock
+}
+
+func (s *reloadingTokenSource) Token() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if s.token != nil && now.Sub(s.loaded) < s.interval {
+		return s.token, nil
+	}
+	token, err := s.loader()
+	if err == nil && token == nil {
+		err = errors.New("oauth1: TokenLoader returned a nil Token")
+	}
+	if err != nil {
+		if s.token != nil {
+			return s.token, nil
+		}
+		return nil, err
+	}
+	copied := *token
+	s.token, s.loaded = &copied, now
+	return s.token, nil
+}
+
+func (s *reloadingTokenSource) now() time.Time {
+	if s.clock != nil {
+		return s.clock.Now()
+	}
+	return time.Now()
+}
+
+// tokenFile is the JSON format of a Token file.
+type tokenFile struct {
+	Token       string `json:"token"`
+	TokenSecret string `json:"token_secret"`
+}
+
+// FileTokenLoader returns a TokenLoader which reads a Token from a JSON file
+// (e.g. {"token": "...", "token_secret": "..."}). The file is only read
+// again when its modification time or size changes. Use it with
+// ReloadingTokenSource to pick up a rotated Token without restarting.
+// Replace the file atomically (e.g. write and rename) so a partially
+// written file is not read.
+func FileTokenLoader(path string) TokenLoader {
+	var (
+		mu      sync.Mutex
+		modTime time.Time
+		size    int64
+		token   *Token
+	)
+	return func() (*Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if token != nil && info.ModTime().Equal(modTime) && info.Size() == size {
+			return token, nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var data tokenFile
+		if err := json.Unmarshal(b, &data); err != nil {
+			return nil, fmt.Errorf("oauth1: error decoding token file %s: %v", path, err)
+		}
+		if data.Token == "" || data.TokenSecret == "" {
+			return nil, fmt.Errorf("oauth1: token file %s missing token or token_secret", path)
+		}
+		token = NewToken(data.Token, data.TokenSecret)
+		modTime, size = info.ModTime(), info.Size()
+		return token, nil
+	}
+}
@@ -0,0 +1,147 @@
+package oauth1
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwappableTokenSource(t *testing.T) {
+	token := NewToken("token", "secret")
+	source := NewSwappableTokenSource(token)
+	// assert a copy is stored
+	token.TokenSecret = "modified"
+	actual, err := source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, NewToken("token", "secret"), actual)
+
+	source.Set(NewToken("rotated", "rotated_secret"))
+	actual, err = source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, NewToken("rotated", "rotated_secret"), actual)
+
+	source.Set(nil)
+	actual, err = source.Token()
+	assert.Nil(t, actual)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: Token is nil", err.Error())
+	}
+}
+
+func TestSwappableTokenSource_Client(t *testing.T) {
+	var mu sync.Mutex
+	var tokens []string
+	config := NewConfig("consumer_key", "consumer_secret")
+	source := NewSwappableTokenSource(NewToken("token_a", "secret_a"))
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params, err := RequestOAuthParams(req)
+		assert.Nil(t, err)
+		// assert each request is signed with a consistent token and secret
+		secret := map[string]string{"token_a": "secret_a", "token_b": "secret_b"}[params[oauthTokenParam]]
+		_, err = config.VerifyRequest(req, secret)
+		assert.Nil(t, err)
+		mu.Lock()
+		tokens = append(tokens, params[oauthTokenParam])
+		mu.Unlock()
+	})
+	defer server.Close()
+
+	client := config.TokenSourceClient(NoContext, source)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i == 10 {
+				source.Set(NewToken("token_b", "secret_b"))
+			}
+			_, err := client.Get(server.URL + "/resource")
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+	_, err := client.Get(server.URL + "/resource")
+	assert.Nil(t, err)
+	assert.Len(t, tokens, 21)
+	assert.Equal(t, "token_b", tokens[20])
+}
+
+func TestReloadingTokenSource(t *testing.T) {
+	clock := &fixedClock{time.Unix(50037133, 0)}
+	loads := 0
+	loader := func() (*Token, error) {
+		loads++
+		if loads == 3 {
+			return nil, errors.New("unavailable")
+		}
+		return NewToken("token", "secret"), nil
+	}
+	source := ReloadingTokenSource(loader, time.Minute).(*reloadingTokenSource)
+	source.clock = clock
+
+	token, err := source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, NewToken("token", "secret"), token)
+	// assert the token is not reloaded within the interval
+	clock.now = clock.now.Add(30 * time.Second)
+	_, err = source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, loads)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, err = source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, loads)
+
+	// assert failed reloads keep the previous token and retry
+	clock.now = clock.now.Add(time.Minute)
+	token, err = source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, NewToken("token", "secret"), token)
+	_, err = source.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, loads)
+}
+
+func TestReloadingTokenSource_LoaderError(t *testing.T) {
+	source := ReloadingTokenSource(func() (*Token, error) {
+		return nil, nil
+	}, time.Minute)
+	token, err := source.Token()
+	assert.Nil(t, token)
+	if assert.Error(t, err) {
+		assert.Equal(t, "oauth1: TokenLoader returned a nil Token", err.Error())
+	}
+}
+
+func TestFileTokenLoader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"token": "token", "token_secret": "secret"}`), 0600))
+	loader := FileTokenLoader(path)
+	token, err := loader()
+	assert.Nil(t, err)
+	assert.Equal(t, NewToken("token", "secret"), token)
+
+	// assert the file is read again when it changes
+	assert.Nil(t, os.WriteFile(path, []byte(`{"token": "rotated", "token_secret": "rotated_secret"}`), 0600))
+	modTime := time.Now().Add(time.Second)
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	token, err = loader()
+	assert.Nil(t, err)
+	assert.Equal(t, NewToken("rotated", "rotated_secret"), token)
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"token": "rotated"}`), 0600))
+	_, err = loader()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "missing token or token_secret")
+	}
+	assert.Nil(t, os.Remove(path))
+	_, err = loader()
+	assert.True(t, os.IsNotExist(err))
+}
@@ -1,8 +1,13 @@
 package oauth1
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 // Transport is an http.RoundTripper which makes OAuth1 HTTP requests. It
@@ -19,28 +24,123 @@ type Transport struct {
 	source TokenSource
 	// auther adds OAuth1 Authorization headers to requests
 	auther *auther
+	// twoLegged signs requests without a token when the request context
+	// does not provide one
+	twoLegged bool
 }
 
 // RoundTrip authorizes the request with a signed OAuth1 Authorization header
-// using the auther and TokenSource.
+// using the auther and TokenSource. RequestOptions carried by the request
+// context may override the token and signing parameters or skip signing.
+// Requests to hosts the Config does not allow are sent unsigned. If the
+// Config has an OnTokenInvalid func, responses which show the access token
+// is invalid are returned as a *TokenInvalidError.
 func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
-	if t.source == nil {
-		return nil, fmt.Errorf("oauth1: Transport's source is nil")
+	opts := requestOptionsFromContext(req.Context())
+	if opts.Unsigned || !t.allowed(req) {
+		return t.base().RoundTrip(req)
 	}
-	accessToken, err := t.source.Token()
-	if err != nil {
-		return nil, err
+	accessToken := opts.Token
+	if accessToken == nil && !t.twoLegged {
+		if t.source == nil {
+			return nil, fmt.Errorf("oauth1: Transport's source is nil")
+		}
+		var err error
+		accessToken, err = t.source.Token()
+		if err != nil {
+			return nil, err
+		}
 	}
 	if t.auther == nil {
 		return nil, fmt.Errorf("oauth1: Transport's auther is nil")
 	}
 	// RoundTripper should not modify the given request, clone it
 	req2 := cloneRequest(req)
-	err = t.auther.setRequestAuthHeader(req2, accessToken)
+	if opts.RequestorID != "" {
+		setQueryParam(req2, xoauthRequestorIDParam, opts.RequestorID)
+	}
+	err := t.auther.setRequestAuthHeaderWithOptions(req2, accessToken, opts)
 	if err != nil {
 		return nil, err
 	}
-	return t.base().RoundTrip(req2)
+	resp, err := t.base().RoundTrip(req2)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || accessToken == nil || t.auther.config.OnTokenInvalid == nil {
+		return resp, err
+	}
+	return t.checkTokenInvalid(resp, accessToken)
+}
+
+// checkTokenInvalid classifies a 401 Unauthorized response. If the response
+// shows the access token is invalid, the Config OnTokenInvalid func is
+// called and a *TokenInvalidError is returned instead of the response.
+// Otherwise, the response is returned with its body intact.
+func (t *Transport) checkTokenInvalid(resp *http.Response, accessToken *Token) (*http.Response, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxProblemBodySize))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	problem := parseOAuthProblem(resp.Header, body)
+	if !isInvalidTokenResponse(resp.StatusCode, problem, body) {
+		resp.Body = &readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	tokenErr := &TokenInvalidError{
+		Token:      accessToken,
+		StatusCode: resp.StatusCode,
+		Problem:    problem,
+		Body:       body,
+	}
+	t.auther.config.OnTokenInvalid(tokenErr)
+	return nil, tokenErr
+}
+
+// readCloser reads from a Reader and closes a Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// allowed reports whether the Config allows signing the request. When the
+// Config restricts hosts, only requests to the Endpoint hosts or
+// AllowedHosts are signed and redirected requests are only signed if
+// SignRedirects is set.
+func (t *Transport) allowed(req *http.Request) bool {
+	if t.auther == nil || !t.auther.config.RestrictHosts {
+		return true
+	}
+	config := t.auther.config
+	// http.Client sets the Response which caused a redirect
+	if req.Response != nil && !config.SignRedirects {
+		return false
+	}
+	hosts := config.AllowedHosts
+	for _, endpointURL := range []string{config.Endpoint.RequestTokenURL, config.Endpoint.AuthorizeURL, config.Endpoint.AccessTokenURL, config.Endpoint.RevokeURL} {
+		if u, err := url.Parse(endpointURL); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Scheme+"://"+u.Host)
+		}
+	}
+	for _, host := range hosts {
+		if matchHost(host, req.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchHost reports whether the URL matches an allowed host, which may be
+// given with a scheme (e.g. "https://api.twitter.com") to match only that
+// scheme.
+func matchHost(allowed string, u *url.URL) bool {
+	scheme := ""
+	if i := strings.Index(allowed, "://"); i >= 0 {
+		scheme, allowed = allowed[:i], allowed[i+3:]
+	}
+	if scheme != "" && !strings.EqualFold(scheme, u.Scheme) {
+		return false
+	}
+	return strings.EqualFold(allowed, u.Host) || strings.EqualFold(allowed, u.Hostname())
 }
 
 func (t *Transport) base() http.RoundTripper {
@@ -50,6 +150,17 @@ func (t *Transport) base() http.RoundTripper {
 	return http.DefaultTransport
 }
 
+// setQueryParam appends a query parameter to the URL of a cloned request,
+// without modifying the URL of the original request.
+func setQueryParam(req *http.Request, key, value string) {
+	u := *req.URL
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	req.URL = &u
+}
+
 // cloneRequest returns a clone of the given *http.Request with a shallow
 // copy of struct fields and a deep copy of the Header map.
 func cloneRequest(req *http.Request) *http.Request {
@@ -1,8 +1,12 @@
 package oauth1
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -110,6 +114,295 @@ func TestTransport_nilAuther(t *testing.T) {
 	}
 }
 
+func TestTransport_contextToken(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "user_token", params[oauthTokenParam])
+	})
+	defer server.Close()
+
+	tr := &Transport{
+		source: StaticTokenSource(NewToken("default_token", "default_secret")),
+		auther: newAuther(NewConfig("consumer_key", "consumer_secret")),
+	}
+	client := &http.Client{Transport: tr}
+	ctx := ContextWithToken(context.Background(), NewToken("user_token", "user_secret"))
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	assert.Nil(t, err)
+	resp, err := client.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransport_contextTokenWithoutSource(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "user_token", params[oauthTokenParam])
+	})
+	defer server.Close()
+
+	// a single client without a TokenSource may sign for many users
+	tr := &Transport{
+		auther: newAuther(NewConfig("consumer_key", "consumer_secret")),
+	}
+	client := &http.Client{Transport: tr}
+	ctx := ContextWithToken(context.Background(), NewToken("user_token", "user_secret"))
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	assert.Nil(t, err)
+	_, err = client.Do(req)
+	assert.Nil(t, err)
+}
+
+func TestTransport_contextRealmAndParams(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.Equal(t, "photos", params[realmParam])
+		assert.Equal(t, "alice", params["xoauth_requestor_id"])
+		assert.Equal(t, "oob", params[oauthCallbackParam])
+		assert.NotEmpty(t, params[oauthSignatureParam])
+	})
+	defer server.Close()
+
+	tr := &Transport{
+		source: StaticTokenSource(NewToken("token", "secret")),
+		auther: newAuther(&Config{ConsumerKey: "consumer_key", Realm: "default"}),
+	}
+	client := &http.Client{Transport: tr}
+	ctx := ContextWithRequestOptions(context.Background(), RequestOptions{
+		Realm: "photos",
+		Params: map[string]string{
+			"xoauth_requestor_id": "alice",
+			oauthCallbackParam:    "oob",
+		},
+	})
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	assert.Nil(t, err)
+	_, err = client.Do(req)
+	assert.Nil(t, err)
+}
+
+func TestTransport_contextInvalidParams(t *testing.T) {
+	cases := []struct {
+		key      string
+		errorMsg string
+	}{
+		{oauthSignatureParam, `oauth1: parameter "oauth_signature" is reserved`},
+		{oauthTokenParam, `oauth1: parameter "oauth_token" is reserved`},
+		{"status", `oauth1: parameter "status" is not an oauth_ or xoauth_ parameter`},
+	}
+	tr := &Transport{
+		source: StaticTokenSource(NewToken("token", "secret")),
+		auther: newAuther(NewConfig("consumer_key", "consumer_secret")),
+	}
+	client := &http.Client{Transport: tr}
+	for _, c := range cases {
+		ctx := ContextWithRequestOptions(context.Background(), RequestOptions{
+			Params: map[string]string{c.key: "value"},
+		})
+		req, err := http.NewRequestWithContext(ctx, "GET", "http://example.com", nil)
+		assert.Nil(t, err)
+		resp, err := client.Do(req)
+		assert.Nil(t, resp)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), c.errorMsg)
+		}
+	}
+}
+
+func TestTransport_contextUnsigned(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.Header.Get(authorizationHeaderParam))
+	})
+	defer server.Close()
+
+	// unsigned requests do not require a token
+	tr := &Transport{
+		auther: newAuther(NewConfig("consumer_key", "consumer_secret")),
+	}
+	client := &http.Client{Transport: tr}
+	ctx := ContextWithRequestOptions(context.Background(), RequestOptions{Unsigned: true})
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	assert.Nil(t, err)
+	_, err = client.Do(req)
+	assert.Nil(t, err)
+}
+
+func TestTransport_restrictHosts(t *testing.T) {
+	other := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.Header.Get(authorizationHeaderParam))
+	})
+	defer other.Close()
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.NotEmpty(t, req.Header.Get(authorizationHeaderParam))
+	})
+	defer server.Close()
+
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		Endpoint: Endpoint{
+			AccessTokenURL: server.URL + "/oauth/access_token",
+		},
+		RestrictHosts: true,
+	}
+	client := config.Client(NoContext, NewToken("token", "secret"))
+	// assert requests to the Endpoint host are signed, other hosts are not
+	_, err := client.Get(server.URL + "/api")
+	assert.Nil(t, err)
+	_, err = client.Get(other.URL + "/api")
+	assert.Nil(t, err)
+}
+
+func TestTransport_restrictHostsRedirect(t *testing.T) {
+	other := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.Header.Get(authorizationHeaderParam))
+	})
+	defer other.Close()
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.NotEmpty(t, req.Header.Get(authorizationHeaderParam))
+		http.Redirect(w, req, other.URL+"/media", http.StatusFound)
+	})
+	defer server.Close()
+
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		RestrictHosts:  true,
+		AllowedHosts:   []string{server.URL},
+		SignRedirects:  true,
+	}
+	client := config.Client(NoContext, NewToken("token", "secret"))
+	// assert redirects to other hosts are not signed
+	resp, err := client.Get(server.URL + "/api")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransport_signRedirects(t *testing.T) {
+	var signed []string
+	var server *httptest.Server
+	server = newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		signed = append(signed, req.Header.Get(authorizationHeaderParam))
+		if req.URL.Path == "/api" {
+			http.Redirect(w, req, server.URL+"/moved", http.StatusFound)
+		}
+	})
+	defer server.Close()
+
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		RestrictHosts:  true,
+		AllowedHosts:   []string{server.URL},
+	}
+	client := config.Client(NoContext, NewToken("token", "secret"))
+	// assert allowed redirects are unsigned unless SignRedirects is set
+	_, err := client.Get(server.URL + "/api")
+	assert.Nil(t, err)
+	if assert.Len(t, signed, 2) {
+		assert.NotEmpty(t, signed[0])
+		assert.Empty(t, signed[1])
+	}
+
+	signed = nil
+	config.SignRedirects = true
+	_, err = client.Get(server.URL + "/api")
+	assert.Nil(t, err)
+	if assert.Len(t, signed, 2) {
+		assert.NotEmpty(t, signed[0])
+		assert.NotEmpty(t, signed[1])
+		assert.NotEqual(t, signed[0], signed[1])
+	}
+}
+
+func TestTransport_tokenInvalid(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(wwwAuthenticateHeader, `OAuth realm="api", oauth_problem="token_revoked"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	defer server.Close()
+
+	var invalid []*TokenInvalidError
+	config := NewConfig("consumer_key", "consumer_secret")
+	config.OnTokenInvalid = func(err *TokenInvalidError) {
+		invalid = append(invalid, err)
+	}
+	token := NewToken("token", "secret")
+	resp, err := config.Client(NoContext, token).Get(server.URL)
+	assert.Nil(t, resp)
+	var tokenErr *TokenInvalidError
+	if assert.True(t, errors.As(err, &tokenErr)) {
+		assert.Equal(t, token, tokenErr.Token)
+		assert.Equal(t, http.StatusUnauthorized, tokenErr.StatusCode)
+		assert.Equal(t, "token_revoked", tokenErr.Problem)
+		assert.Equal(t, "oauth1: access token is invalid: token_revoked", tokenErr.Error())
+	}
+	assert.Equal(t, []*TokenInvalidError{tokenErr}, invalid)
+}
+
+func TestTransport_tokenInvalidOtherUnauthorized(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(contentType, formContentType)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("oauth_problem=signature_invalid"))
+	})
+	defer server.Close()
+
+	config := NewConfig("consumer_key", "consumer_secret")
+	config.OnTokenInvalid = func(err *TokenInvalidError) {
+		t.Errorf("unexpected OnTokenInvalid call: %v", err)
+	}
+	resp, err := config.Client(NoContext, NewToken("token", "secret")).Get(server.URL)
+	assert.Nil(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		// assert the classified body can still be read
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "oauth_problem=signature_invalid", string(body))
+	}
+}
+
+func TestTransport_tokenInvalidNotConfigured(t *testing.T) {
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(contentType, formContentType)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("oauth_problem=token_expired"))
+	})
+	defer server.Close()
+
+	// assert responses are returned as is without an OnTokenInvalid func
+	config := NewConfig("consumer_key", "consumer_secret")
+	resp, err := config.Client(NoContext, NewToken("token", "secret")).Get(server.URL)
+	assert.Nil(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	cases := []struct {
+		allowed string
+		url     string
+		match   bool
+	}{
+		{"api.twitter.com", "https://api.twitter.com/1.1/", true},
+		{"API.twitter.com", "http://api.twitter.com:8080/", true},
+		{"https://api.twitter.com", "https://api.twitter.com/1.1/", true},
+		{"https://api.twitter.com", "http://api.twitter.com/1.1/", false},
+		{"api.twitter.com:8080", "http://api.twitter.com:8080/", true},
+		{"api.twitter.com:8080", "http://api.twitter.com:9090/", false},
+		{"api.twitter.com", "https://pbs.twimg.com/media", false},
+		{"twitter.com", "https://api.twitter.com/", false},
+		{"[::1]:8080", "http://[::1]:8080/", true},
+	}
+	for _, c := range cases {
+		u, err := url.Parse(c.url)
+		assert.Nil(t, err)
+		assert.Equal(t, c.match, matchHost(c.allowed, u), "%s %s", c.allowed, c.url)
+	}
+}
+
 func newMockServer(handler func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(handler))
 }
@@ -0,0 +1,57 @@
+package tumblr
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dghubble/oauth1"
+)
+
+const userInfoURL = "https://api.tumblr.com/v2/user/info"
+
+// IdentityProvider looks up the Tumblr user who authorized an access Token
+// with the user/info API.
+type IdentityProvider struct {
+	// URL of the user info API (defaults to the Tumblr API)
+	URL string
+}
+
+var _ oauth1.IdentityProvider = IdentityProvider{}
+
+type userInfoResponse struct {
+	Response struct {
+		User struct {
+			Name  string `json:"name"`
+			Blogs []struct {
+				UUID    string `json:"uuid"`
+				Primary bool   `json:"primary"`
+			} `json:"blogs"`
+		} `json:"user"`
+	} `json:"response"`
+}
+
+// Identity returns the UUID of the user's primary blog as the ID, since
+// Tumblr user names can be changed, and the user's name as the username.
+func (p IdentityProvider) Identity(ctx context.Context, config *oauth1.Config, token *oauth1.Token) (*oauth1.Identity, error) {
+	rawURL := p.URL
+	if rawURL == "" {
+		rawURL = userInfoURL
+	}
+	var info userInfoResponse
+	raw, err := oauth1.GetIdentityJSON(ctx, config, token, rawURL, &info)
+	if err != nil {
+		return nil, err
+	}
+	user := info.Response.User
+	var id string
+	for _, blog := range user.Blogs {
+		if blog.Primary {
+			id = blog.UUID
+			break
+		}
+	}
+	if id == "" {
+		return nil, errors.New("tumblr: Response missing primary blog uuid")
+	}
+	return &oauth1.Identity{ID: id, Username: user.Name, Raw: raw}, nil
+}
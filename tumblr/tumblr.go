@@ -1,4 +1,5 @@
-// Package tumblr provides constants for using OAuth 1 to access Tumblr.
+// Package tumblr provides constants and an IdentityProvider for using OAuth 1
+// to access Tumblr.
 package tumblr
 
 import (
@@ -0,0 +1,41 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dghubble/oauth1"
+)
+
+const verifyCredentialsURL = "https://api.twitter.com/1.1/account/verify_credentials.json"
+
+// IdentityProvider looks up the Twitter user who authorized an access Token
+// with the account/verify_credentials API.
+type IdentityProvider struct {
+	// URL of the verify credentials API (defaults to the Twitter API)
+	URL string
+}
+
+var _ oauth1.IdentityProvider = IdentityProvider{}
+
+type twitterUser struct {
+	ID         string `json:"id_str"`
+	ScreenName string `json:"screen_name"`
+}
+
+// Identity returns the user's ID and screen name.
+func (p IdentityProvider) Identity(ctx context.Context, config *oauth1.Config, token *oauth1.Token) (*oauth1.Identity, error) {
+	rawURL := p.URL
+	if rawURL == "" {
+		rawURL = verifyCredentialsURL
+	}
+	var user twitterUser
+	raw, err := oauth1.GetIdentityJSON(ctx, config, token, rawURL, &user)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == "" {
+		return nil, errors.New("twitter: Response missing id_str")
+	}
+	return &oauth1.Identity{ID: user.ID, Username: user.ScreenName, Raw: raw}, nil
+}
@@ -1,4 +1,5 @@
-// Package twitter provides constants for using OAuth1 to access Twitter.
+// Package twitter provides constants and an IdentityProvider for using OAuth1
+// to access Twitter.
 package twitter
 
 import (
@@ -12,6 +13,7 @@ var AuthenticateEndpoint = oauth1.Endpoint{
 	RequestTokenURL: "https://api.twitter.com/oauth/request_token",
 	AuthorizeURL:    "https://api.twitter.com/oauth/authenticate",
 	AccessTokenURL:  "https://api.twitter.com/oauth/access_token",
+	RevokeURL:       "https://api.twitter.com/1.1/oauth/invalidate_token",
 }
 
 // AuthorizeEndpoint is Twitter's OAuth 1 endpoint which uses the
@@ -22,4 +24,5 @@ var AuthorizeEndpoint = oauth1.Endpoint{
 	RequestTokenURL: "https://api.twitter.com/oauth/request_token",
 	AuthorizeURL:    "https://api.twitter.com/oauth/authorize",
 	AccessTokenURL:  "https://api.twitter.com/oauth/access_token",
+	RevokeURL:       "https://api.twitter.com/1.1/oauth/invalidate_token",
 }
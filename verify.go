@@ -0,0 +1,179 @@
+package oauth1
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidSignature is returned when a request's oauth_signature does not
+// match the signature computed by the verifier.
+var ErrInvalidSignature = errors.New("oauth1: invalid signature")
+
+// ErrInvalidBodyHash is returned when a request's oauth_body_hash does not
+// match the hash of the request body.
+var ErrInvalidBodyHash = errors.New("oauth1: invalid body hash")
+
+// VerifyRequest verifies the OAuth1 signature of a request received by a
+// provider (server), given the secret of the token the request was signed
+// with ("" for two-legged requests). Protocol parameters are read from the
+// Authorization header or, if there is none, from the form body and query.
+// Returns the protocol parameters, so the caller can check the timestamp,
+// nonce, and token.
+//
+// If the request has an oauth_body_hash parameter, the request body is read
+// and its hash checked. The body is replaced so the caller can still read it.
+//
+// The signature is computed with the Config Signer, so only HMAC signature
+// methods can be verified. Requests received behind a TLS terminating proxy
+// should have req.URL.Scheme set to the scheme the client used.
+func (c *Config) VerifyRequest(req *http.Request, tokenSecret string) (map[string]string, error) {
+	// parse the query and form encoded body into req.Form
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	oauthParams, err := requestOAuthParams(req)
+	if err != nil {
+		return nil, err
+	}
+	signature := oauthParams[oauthSignatureParam]
+	if signature == "" {
+		return nil, errors.New("oauth1: Request missing oauth_signature")
+	}
+	if oauthParams[oauthConsumerKeyParam] != c.ConsumerKey {
+		return nil, errors.New("oauth1: unknown oauth_consumer_key")
+	}
+	a := newAuther(c)
+	if method := oauthParams[oauthSignatureMethodParam]; method != a.signer().Name() {
+		return nil, fmt.Errorf("oauth1: unsupported oauth_signature_method %q", method)
+	}
+
+	params := map[string]string{}
+	for key, values := range req.Form {
+		// not supporting params with duplicate keys
+		params[key] = values[0]
+	}
+	for key, value := range oauthParams {
+		// according to 3.4.1.3.1. the realm parameter is excluded
+		if key != realmParam {
+			params[key] = value
+		}
+	}
+	delete(params, oauthSignatureParam)
+	expected, err := a.signer().Sign(tokenSecret, signatureBase(serverRequestURL(req), params))
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return nil, ErrInvalidSignature
+	}
+	if hash, ok := oauthParams[oauthBodyHashParam]; ok {
+		if err := verifyBodyHash(req, a.signer().Name(), hash); err != nil {
+			return nil, err
+		}
+	}
+	return oauthParams, nil
+}
+
+// RequestOAuthParams returns the protocol parameters of a received request,
+// from the Authorization header or, if there is none, from the form body and
+// query. The parameters are not verified, but may be used to look up the
+// consumer and token secrets needed to call VerifyRequest.
+func RequestOAuthParams(req *http.Request) (map[string]string, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	return requestOAuthParams(req)
+}
+
+// verifyBodyHash checks the oauth_body_hash of a received request against
+// the hash of its body, replacing the body so it can still be read.
+func verifyBodyHash(req *http.Request, signatureMethod, hash string) error {
+	if isFormContentType(req.Header.Get(contentType)) {
+		return errors.New("oauth1: oauth_body_hash must not be used with form encoded bodies")
+	}
+	var b []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		b, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+	expected := bodyHashValue(signatureMethod, b)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) != 1 {
+		return ErrInvalidBodyHash
+	}
+	return nil
+}
+
+// requestOAuthParams returns the protocol parameters of a received request,
+// from the Authorization header or the parsed form and query.
+func requestOAuthParams(req *http.Request) (map[string]string, error) {
+	if header := req.Header.Get(authorizationHeaderParam); header != "" {
+		return parseAuthHeader(header)
+	}
+	params := map[string]string{}
+	for key, values := range req.Form {
+		if strings.HasPrefix(key, "oauth_") {
+			params[key] = values[0]
+		}
+	}
+	return params, nil
+}
+
+// parseAuthHeader parses the parameters of an "OAuth" Authorization header
+// value formatted according to RFC 5849 3.5.1.
+func parseAuthHeader(header string) (map[string]string, error) {
+	if len(header) < len(authorizationPrefix) || !strings.EqualFold(header[:len(authorizationPrefix)], authorizationPrefix) {
+		return nil, errors.New("oauth1: Authorization header is not an OAuth header")
+	}
+	params := map[string]string{}
+	for _, pair := range strings.Split(header[len(authorizationPrefix):], ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("oauth1: invalid Authorization header parameter %q", pair)
+		}
+		key, err := url.PathUnescape(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := url.PathUnescape(strings.Trim(parts[1], `"`))
+		if err != nil {
+			return nil, err
+		}
+		params[key] = value
+	}
+	return params, nil
+}
+
+// serverRequestURL returns a shallow copy of a received request whose URL
+// has the scheme and host the client used, as needed to compute the base
+// string URI.
+func serverRequestURL(req *http.Request) *http.Request {
+	u := *req.URL
+	if u.Scheme == "" {
+		u.Scheme = "http"
+		if req.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	r2 := new(http.Request)
+	*r2 = *req
+	r2.URL = &u
+	return r2
+}
@@ -0,0 +1,174 @@
+package oauth1
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	config := &Config{
+		ConsumerKey:    "consumer_key",
+		ConsumerSecret: "consumer_secret",
+		Realm:          "photos",
+	}
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params, err := config.VerifyRequest(req, "token_secret")
+		assert.Nil(t, err)
+		assert.Equal(t, "token", params[oauthTokenParam])
+		assert.Equal(t, "photos", params[realmParam])
+		// assert a wrong token secret is rejected
+		_, err = config.VerifyRequest(req, "wrong_secret")
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+	defer server.Close()
+
+	client := config.Client(NoContext, NewToken("token", "token_secret"))
+	values := url.Values{"status": {"Hello Ladies + Gentlemen"}}
+	req, err := http.NewRequest("POST", server.URL+"/statuses/update.json?include_entities=true", strings.NewReader(values.Encode()))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, formContentType+"; charset=UTF-8")
+	_, err = client.Do(req)
+	assert.Nil(t, err)
+}
+
+func TestVerifyRequest_EmptyPath(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/", req.URL.Path)
+		_, err := config.VerifyRequest(req, "token_secret")
+		assert.Nil(t, err)
+	})
+	defer server.Close()
+
+	// assert a URL without a path is signed as "/", as it is sent
+	client := config.Client(NoContext, NewToken("token", "token_secret"))
+	_, err := client.Get(server.URL)
+	assert.Nil(t, err)
+}
+
+func TestVerifyRequest_SignForm(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.Header.Get(authorizationHeaderParam))
+		params, err := config.VerifyRequest(req, "")
+		assert.Nil(t, err)
+		assert.Equal(t, "consumer_key", params[oauthConsumerKeyParam])
+		assert.Equal(t, "resource", req.PostForm.Get("resource_link_id"))
+	})
+	defer server.Close()
+
+	form := url.Values{"resource_link_id": {"resource"}}
+	signed, err := config.SignForm("POST", server.URL+"/launch", form, nil)
+	assert.Nil(t, err)
+	// assert the form is copied and protocol parameters added
+	assert.Equal(t, url.Values{"resource_link_id": {"resource"}}, form)
+	assert.NotEmpty(t, signed.Get(oauthSignatureParam))
+	assert.NotEmpty(t, signed.Get(oauthNonceParam))
+	_, ok := signed[oauthTokenParam]
+	assert.False(t, ok)
+	_, err = http.PostForm(server.URL+"/launch", signed)
+	assert.Nil(t, err)
+}
+
+func TestVerifyRequest_Tampered(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	signed, err := config.SignForm("POST", "https://tool.example.com/launch", url.Values{"user_id": {"1"}}, nil)
+	assert.Nil(t, err)
+	signed.Set("user_id", "2")
+	req, err := http.NewRequest("POST", "https://tool.example.com/launch", strings.NewReader(signed.Encode()))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, formContentType)
+	params, err := config.VerifyRequest(req, "")
+	assert.Nil(t, params)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerifyRequest_Invalid(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	cases := []struct {
+		authHeader string
+		errorMsg   string
+	}{
+		{`OAuth oauth_consumer_key="consumer_key"`, "oauth1: Request missing oauth_signature"},
+		{`OAuth oauth_consumer_key="other", oauth_signature="sig"`, "oauth1: unknown oauth_consumer_key"},
+		{`OAuth oauth_consumer_key="consumer_key", oauth_signature="sig", oauth_signature_method="PLAINTEXT"`, `oauth1: unsupported oauth_signature_method "PLAINTEXT"`},
+		{`Bearer token`, "oauth1: Authorization header is not an OAuth header"},
+	}
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "https://example.com", nil)
+		assert.Nil(t, err)
+		req.Header.Set(authorizationHeaderParam, c.authHeader)
+		params, err := config.VerifyRequest(req, "")
+		assert.Nil(t, params)
+		if assert.Error(t, err) {
+			assert.Equal(t, c.errorMsg, err.Error())
+		}
+	}
+}
+
+func TestParseAuthHeader(t *testing.T) {
+	cases := []struct {
+		header string
+		params map[string]string
+	}{
+		{`OAuth `, map[string]string{}},
+		{`OAuth a="b"`, map[string]string{"a": "b"}},
+		{`OAuth realm="photos", oauth_token="kkk9d7dh3k39sjv7",oauth_signature="wOJIO9A2W5mFwDgiDvZbTSMK%2FPY%3D"`, map[string]string{
+			"realm":           "photos",
+			"oauth_token":     "kkk9d7dh3k39sjv7",
+			"oauth_signature": "wOJIO9A2W5mFwDgiDvZbTSMK/PY=",
+		}},
+		{authHeaderValue(map[string]string{"oauth_callback": "http://example.com/cb?a=b c"}), map[string]string{"oauth_callback": "http://example.com/cb?a=b c"}},
+	}
+	for _, c := range cases {
+		params, err := parseAuthHeader(c.header)
+		assert.Nil(t, err)
+		assert.Equal(t, c.params, params)
+	}
+}
+
+func TestVerifyRequest_BodyHash(t *testing.T) {
+	config := NewConfig("consumer_key", "consumer_secret")
+	body := "<xml>Hello World!</xml>"
+	server := newMockServer(func(w http.ResponseWriter, req *http.Request) {
+		params := parseOAuthParamsOrFail(t, req.Header.Get(authorizationHeaderParam))
+		assert.NotEmpty(t, params[oauthBodyHashParam])
+		_, err := config.VerifyRequest(req, "")
+		assert.Nil(t, err)
+		// assert the body can still be read after verification
+		b, err := ioutil.ReadAll(req.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, body, string(b))
+		// assert a modified body is rejected
+		req.Body = ioutil.NopCloser(strings.NewReader("<xml>Goodbye</xml>"))
+		_, err = config.VerifyRequest(req, "")
+		assert.Equal(t, ErrInvalidBodyHash, err)
+	})
+	defer server.Close()
+
+	client := config.TwoLeggedClient(NoContext)
+	ctx := ContextWithRequestOptions(NoContext, RequestOptions{BodyHash: true})
+	req, err := http.NewRequestWithContext(ctx, "POST", server.URL+"/outcomes", strings.NewReader(body))
+	assert.Nil(t, err)
+	req.Header.Set(contentType, "application/xml")
+	_, err = client.Do(req)
+	assert.Nil(t, err)
+}
+
+func TestRequestOAuthParams(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://example.com/launch?oauth_consumer_key=consumer_key", nil)
+	assert.Nil(t, err)
+	params, err := RequestOAuthParams(req)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{oauthConsumerKeyParam: "consumer_key"}, params)
+
+	req.Header.Set(authorizationHeaderParam, `OAuth oauth_consumer_key="other%20key", oauth_nonce="abc"`)
+	params, err = RequestOAuthParams(req)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{oauthConsumerKeyParam: "other key", oauthNonceParam: "abc"}, params)
+}
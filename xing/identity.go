@@ -0,0 +1,44 @@
+package xing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dghubble/oauth1"
+)
+
+const usersMeURL = "https://api.xing.com/v1/users/me"
+
+// IdentityProvider looks up the Xing user who authorized an access Token
+// with the users/me API.
+type IdentityProvider struct {
+	// URL of the users/me API (defaults to the Xing API)
+	URL string
+}
+
+var _ oauth1.IdentityProvider = IdentityProvider{}
+
+type usersResponse struct {
+	Users []struct {
+		ID       string `json:"id"`
+		PageName string `json:"page_name"`
+	} `json:"users"`
+}
+
+// Identity returns the user's ID and page name.
+func (p IdentityProvider) Identity(ctx context.Context, config *oauth1.Config, token *oauth1.Token) (*oauth1.Identity, error) {
+	rawURL := p.URL
+	if rawURL == "" {
+		rawURL = usersMeURL
+	}
+	var users usersResponse
+	raw, err := oauth1.GetIdentityJSON(ctx, config, token, rawURL, &users)
+	if err != nil {
+		return nil, err
+	}
+	if len(users.Users) == 0 || users.Users[0].ID == "" {
+		return nil, errors.New("xing: Response missing user id")
+	}
+	user := users.Users[0]
+	return &oauth1.Identity{ID: user.ID, Username: user.PageName, Raw: raw}, nil
+}
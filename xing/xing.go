@@ -1,4 +1,5 @@
-// Package xing provides constants for using OAuth1 to access Xing.
+// Package xing provides constants and an IdentityProvider for using OAuth1 to
+// access Xing.
 package xing
 
 import (